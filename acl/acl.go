@@ -0,0 +1,234 @@
+// Package acl keeps who may read and write each topic. A topic without an
+// access list is open to everyone. The first author to change the list of a
+// topic becomes its owner, and from then on only the owner can change it.
+//
+// A topic others already use can't be claimed, or anyone could take over a
+// busy topic, make it private and ban everyone else.
+//
+// Everyone except the banned can read and write an open topic, unless they
+// are read-only. A private topic can only be read by its owner, members and
+// read-only members, and only written by its owner and members.
+package acl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Role is what an author may do on a topic.
+type Role int
+
+const (
+	None Role = iota
+	Banned
+	ReadOnly
+	Member
+	Owner
+)
+
+var names = map[Role]string{None: "none", Banned: "banned", ReadOnly: "read-only", Member: "member", Owner: "owner"}
+
+func (r Role) String() string {
+	return names[r]
+}
+
+// MarshalText writes the role by its name, which keeps the file readable.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText reads a role written by MarshalText.
+func (r *Role) UnmarshalText(text []byte) error {
+	for role, name := range names {
+		if name == string(text) {
+			*r = role
+			return nil
+		}
+	}
+	return errors.New("unknown role " + string(text))
+}
+
+// ErrNotOwner is returned when someone other than the owner changes a list.
+var ErrNotOwner = errors.New("only the owner can change who may use the topic")
+
+// ErrInUse is returned when claiming a topic that others already use.
+var ErrInUse = errors.New("the topic is already in use and can't be claimed")
+
+// List is the access list of one topic.
+type List struct {
+	Owner   string          `json:"owner"`
+	Private bool            `json:"private"`
+	Roles   map[string]Role `json:"roles,omitempty"`
+}
+
+// Role returns the role of author on the topic.
+func (l *List) Role(author string) Role {
+	if author == l.Owner {
+		return Owner
+	}
+	return l.Roles[author]
+}
+
+// Set gives author a role, replacing the one they had. Giving the owner's
+// role to someone else hands the topic over, and the old owner becomes a
+// member.
+func (l *List) Set(author string, role Role) error {
+	if author == "" {
+		return errors.New("no name given")
+	}
+	if author == l.Owner {
+		return errors.New(author + " owns the topic")
+	}
+	if l.Roles == nil {
+		l.Roles = map[string]Role{}
+	}
+	delete(l.Roles, author)
+	switch role {
+	case None:
+	case Owner:
+		l.Roles[l.Owner] = Member
+		l.Owner = author
+	default:
+		l.Roles[author] = role
+	}
+	return nil
+}
+
+// With returns the authors that have role, sorted by name.
+func (l *List) With(role Role) []string {
+	var authors []string
+	for author, r := range l.Roles {
+		if r == role {
+			authors = append(authors, author)
+		}
+	}
+	sort.Strings(authors)
+	return authors
+}
+
+// CanRead reports whether author may receive the messages on the topic.
+func (l *List) CanRead(author string) bool {
+	role := l.Role(author)
+	return role != Banned && (!l.Private || role != None)
+}
+
+// CanWrite reports whether author may send messages to the topic.
+func (l *List) CanWrite(author string) bool {
+	role := l.Role(author)
+	return role != Banned && role != ReadOnly && (!l.Private || role != None)
+}
+
+func (l *List) copy() List {
+	c := *l
+	c.Roles = map[string]Role{}
+	for author, role := range l.Roles {
+		c.Roles[author] = role
+	}
+	return c
+}
+
+// Store is the access lists of all topics. It is safe for concurrent use.
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	lists map[string]*List
+}
+
+// Open reads the lists kept in the file at path, which doesn't have to
+// exist yet. Every change is written back to it. With an empty path the
+// lists are only kept in memory.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, lists: map[string]*List{}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.lists); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the list of topic, if it has one.
+func (s *Store) Get(topic string) (List, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, found := s.lists[topic]
+	if !found {
+		return List{}, false
+	}
+	return l.copy(), true
+}
+
+// CanRead reports whether author may receive the messages on topic.
+func (s *Store) CanRead(topic, author string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, found := s.lists[topic]
+	return !found || l.CanRead(author)
+}
+
+// CanWrite reports whether author may send messages to topic.
+func (s *Store) CanWrite(topic, author string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, found := s.lists[topic]
+	return !found || l.CanWrite(author)
+}
+
+// Change lets author change the list of topic and returns the new list. If
+// the topic has no list yet, author becomes its owner if mayClaim is set,
+// and otherwise ErrInUse is returned. If change returns an error the list is
+// left as it was.
+func (s *Store) Change(topic, author string, mayClaim bool, change func(l *List) error) (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, found := s.lists[topic]
+	if !found && !mayClaim {
+		return List{}, ErrInUse
+	} else if !found {
+		l = &List{Owner: author}
+	} else if l.Owner != author {
+		return List{}, ErrNotOwner
+	}
+	changed := l.copy()
+	if err := change(&changed); err != nil {
+		return List{}, err
+	}
+	s.lists[topic] = &changed
+	if err := s.save(); err != nil {
+		if found {
+			s.lists[topic] = l
+		} else {
+			delete(s.lists, topic)
+		}
+		return List{}, err
+	}
+	return changed.copy(), nil
+}
+
+// save writes all lists to the file, through a temporary file so a crash
+// can't leave half of it.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.lists, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
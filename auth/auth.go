@@ -0,0 +1,318 @@
+// Package auth lets chat authors log in with a password and prove who they
+// are afterwards with a signed token. The token is sent as
+// "authorization: Bearer TOKEN" metadata on every call, and the server
+// interceptors put the author it was issued to in the context of the call.
+//
+// With mutual TLS the client certificate proves who the author is instead:
+// the common name of the certificate is the author, and no token is needed.
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Users is the bcrypt hash of the password of every author that can log in.
+type Users map[string][]byte
+
+// LoadUsers reads a users file, which has a line "NAME:HASH" for every
+// author, where HASH is the bcrypt hash of their password. That is the line
+// "htpasswd -nbB NAME PASSWORD" prints. Empty lines and lines starting with
+// "#" are skipped.
+func LoadUsers(path string) (Users, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	users := Users{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, hash, found := strings.Cut(line, ":")
+		if _, err := bcrypt.Cost([]byte(hash)); !found || name == "" || err != nil {
+			return nil, errors.New(path + ":" + strconv.Itoa(n) + ": expected NAME:HASH with a bcrypt hash")
+		}
+		users[name] = []byte(hash)
+	}
+	return users, scanner.Err()
+}
+
+// HashPassword returns the bcrypt hash of password, for a users file.
+func HashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+var (
+	unknownOnce sync.Once
+	unknownHash []byte
+)
+
+// Check reports whether password is the password of author. It takes as
+// long for an author that is not in u, so the time doesn't tell who is.
+func (u Users) Check(author, password string) bool {
+	hash, found := u[author]
+	if !found {
+		unknownOnce.Do(func() {
+			unknownHash, _ = HashPassword("")
+		})
+		hash = unknownHash
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil && found
+}
+
+// Signer issues tokens and verifies them again.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner returns a Signer whose tokens are signed with key and are valid for ttl.
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl}
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue returns a token for author and when it expires.
+func (s *Signer) Issue(author string) (string, time.Time) {
+	expires := time.Now().Add(s.ttl)
+	payload := author + "\n" + strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload), expires
+}
+
+// Verify returns the author a token was issued to, if it was signed by s and
+// has not expired.
+func (s *Signer) Verify(token string) (string, error) {
+	encoded, signature, _ := strings.Cut(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(string(payload)))) {
+		return "", errors.New("token is not valid")
+	}
+	author, expiry, _ := strings.Cut(string(payload), "\n")
+	seconds, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(seconds, 0)) {
+		return "", errors.New("token has expired")
+	}
+	return author, nil
+}
+
+type authorKey struct{}
+
+// NewContext returns a copy of ctx that carries the verified author.
+func NewContext(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+// FromContext returns the verified author of a call, if there is one.
+func FromContext(ctx context.Context) (string, bool) {
+	author, ok := ctx.Value(authorKey{}).(string)
+	return author, ok
+}
+
+// authenticate verifies the token in the metadata of a call, unless the
+// call was already identified by its certificate.
+func (s *Signer) authenticate(ctx context.Context) (context.Context, error) {
+	if _, ok := FromContext(ctx); ok {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "log in first")
+	}
+	author, err := s.Verify(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return NewContext(ctx, author), nil
+}
+
+// UnaryInterceptor checks the token of every unary call except the methods
+// in public, like the one that logs in.
+func (s *Signer) UnaryInterceptor(public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for _, method := range public {
+			if info.FullMethod == method {
+				return handler(ctx, req)
+			}
+		}
+		ctx, err := s.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// identified is a stream whose context carries the verified author.
+type identified struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s identified) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor checks the token of every streaming call.
+func (s *Signer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := s.authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, identified{stream, ctx})
+	}
+}
+
+// Token is the client side of a token. It adds the token to every call once
+// Set was called.
+type Token struct {
+	mu    sync.Mutex
+	token string
+}
+
+// Set replaces the token sent with every call.
+func (t *Token) Set(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = token
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (t *Token) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token == "" {
+		return nil, nil
+	}
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. The
+// token is sent without TLS too, so it is only safe on a trusted network.
+func (t *Token) RequireTransportSecurity() bool {
+	return false
+}
+
+// ServerTLS loads the certificate and key the server shows its clients. If
+// clientCAFile is not empty, clients must show a certificate signed by one
+// of the CAs in it, which is mutual TLS.
+func ServerTLS(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		config.ClientCAs, err = loadPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientTLS trusts the server certificates signed by a CA in caFile, or by
+// the system CAs if it is empty. If certFile is not empty, the client shows
+// that certificate to the server for mutual TLS.
+func ClientTLS(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New(path + ": no certificates found")
+	}
+	return pool, nil
+}
+
+// fromCertificate puts the common name of the verified client certificate
+// of a call in its context as the author.
+func fromCertificate(ctx context.Context) (context.Context, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 {
+		return ctx, nil
+	}
+	author := info.State.VerifiedChains[0][0].Subject.CommonName
+	if author == "" {
+		return nil, status.Error(codes.Unauthenticated, "client certificate has no common name")
+	}
+	return NewContext(ctx, author), nil
+}
+
+// CertificateUnaryInterceptor makes the common name of the client
+// certificate the author of every unary call.
+func CertificateUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := fromCertificate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CertificateStreamInterceptor makes the common name of the client
+// certificate the author of every streaming call.
+func CertificateStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := fromCertificate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, identified{stream, ctx})
+	}
+}
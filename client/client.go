@@ -0,0 +1,899 @@
+/*
+┌──────────────────────────────┐  ┌──────────────────────────────┐
+│                              │  │                              │
+│  Client                      │  │  Server                      │
+│  ------                      │  │  ------                      │
+│                              │  │                              │
+│  Can Request messages        │  │  Handles new Subscribers     │
+│                              │  │                              │
+│  Can Send messages to topic  │  │  Unsubscribes disconnected   │
+│                              │  │  users                       │
+│  Can use Lamport timstamp    │  │                              │
+│  To figure out the order or  │  │  Increment lamport timestamp │
+│  lost messages               │  │  on every action serverside  │
+│                              │  │                              │
+└──────────────────────────────┘  └──────────────────────────────┘
+_________________________________________________________________________________
+
+┌──────────────────────────────┐
+│                              │
+│  Client 1                    │
+│                              │            ┌───────────────────────────────────┐
+│  username : Anders           │ Receive    │                                   │
+│  topic    : itu              ├───────────►│  Server                           │
+│                              │   Messages │  ------                           │
+│  Send     : Send 1 Message   │ ◄───────── │                                   │
+│  Receive  : Get stream of    │   Send     │  EventBus struct:                 │
+│             messages         ├───────────►│    Subscribe                      │
+└──────────────────────────────┘            │    Unsubscribe                    │
+                                            │    Publish                        │
+┌──────────────────────────────┐            │                                   │
+│                              │            │  Send gRPC:                       │
+│  Client 2                    │            │    Will get a message and publish │
+│                              │ Receive    │    it to the EventBus             │
+│  username : Emil             ├───────────►│    Send back ACK to client        │
+│  topic    : itu              │   Messages │                                   │
+│                              │ ◄───────── │  Receive gRPC:                    │
+│  Send     : Send 1 Message   │   Send     │    Open stream to client          │
+│  Receive  : Get stream of    ├───────────►│                                   │
+│             messages         │            │    Subscribe client to topic      │
+└──────────────────────────────┘            │    through EventBus               │
+                                            │                                   │
+┌──────────────────────────────┐            │    Stream messages from EventBus  │
+│                              │ Receive    │    to client                      │
+│  Client 3                    ├───────────►│                                   │
+│                              │   Messages │                                   │
+│  username : Sebastian        │ ◄───────── │                                   │
+│  topic    : itu              │   Send     │                                   │
+│                              ├───────────►└───────────────────────────────────┘
+│  Send     : Send 1 Message   │
+│  Receive  : Get stream of    │
+│             messages         │
+└──────────────────────────────┘
+ */
+
+package main
+
+import (
+    "fmt"
+    "bufio"
+    "os"
+    "os/exec"
+    "os/signal"
+    "io"
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "flag"
+    "sort"
+    "sync"
+    "time"
+    "strings"
+    "syscall"
+    "github.com/AndersStendevad/disys-m3/auth"
+    chat "github.com/AndersStendevad/disys-m3/grpc"
+    "github.com/AndersStendevad/disys-m3/pattern"
+    "github.com/AndersStendevad/disys-m3/vclock"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/encoding/protojson"
+)
+
+var server = flag.String("server", envOr("CHAT_SERVER", "localhost:8080"), "address of the server, \"host:port\" or \"unix:PATH\"; defaults to $CHAT_SERVER")
+var authorFlag = flag.String("author", "", "name to chat as, instead of giving it as the first argument")
+var topicFlag = &topicList{}
+var timeout = flag.Duration("timeout", 0, "how long to wait for the server to answer before giving up; 0 waits as long as it takes")
+var format = flag.String("format", "text", "how messages are printed: text, or json for one JSON object per line")
+var replay = flag.Uint("replay", 0, "show up to this many earlier messages on the topic when joining")
+var window = flag.Duration("window", 200*time.Millisecond, "how long incoming messages are held back to be put in order")
+var password = flag.String("password", os.Getenv("CHAT_PASSWORD"), "password to log in with, for servers that require it; defaults to $CHAT_PASSWORD")
+var useTLS = flag.Bool("tls", false, "connect with TLS")
+var caFile = flag.String("ca", "", "CA file to trust the server certificate from instead of the system CAs; implies -tls")
+var certFile = flag.String("cert", "", "client certificate to show the server for mutual TLS; implies -tls")
+var keyFile = flag.String("key", "", "key file of -cert")
+var readReceipts = flag.Bool("read-receipts", false, "tell the server when you have read a message")
+
+func init() {
+    flag.Var(topicFlag, "topic", "topic to follow, instead of giving them as arguments after the name; can be given several times")
+}
+
+// topicList is the -topic flag, which can be given several times.
+type topicList struct {
+    list []string
+}
+
+func (t *topicList) String() string {
+    return strings.Join(t.list, ",")
+}
+
+func (t *topicList) Set(topic string) error {
+    t.list = append(t.list, topic)
+    return nil
+}
+
+// envOr returns the environment variable name, or value if it is not set.
+func envOr(name string, value string) string {
+    if v, found := os.LookupEnv(name); found {
+        return v
+    }
+    return value
+}
+
+const usage = `Usage:
+  go run ./client [flags] NAME TOPIC [TOPIC...]        chat on the topics
+  go run ./client send [flags] NAME TOPIC [MESSAGE...]  send one message and exit
+  go run ./client tail [flags] NAME TOPIC [TOPIC...]   print the messages on the topics
+
+NAME and TOPIC can also be given with -author and -topic. When send gets no
+MESSAGE it sends what it reads from standard input.
+
+Flags:
+`
+
+var input[]byte
+
+// interactive is set when someone is typing in the terminal, so lines are
+// printed above what they are typing.
+var interactive bool
+
+// Clock is the client's own Lamport clock. It ticks on every Send and Receive
+// and is merged with the timestamp of every message the server streams to us.
+type Clock struct {
+    mu sync.Mutex
+    time uint64
+}
+
+// Tick advances the clock for a local event and returns the new time.
+func (c *Clock) Tick() uint64 {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.time++
+    return c.time
+}
+
+// Merge advances the clock past a received timestamp: max(local, received) + 1.
+func (c *Clock) Merge(received uint64) uint64 {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if received > c.time {
+        c.time = received
+    }
+    c.time++
+    return c.time
+}
+
+var clock Clock
+
+// following is the topics the client receives, and current is the one
+// messages are sent to.
+var following struct {
+    sync.Mutex
+    topics []string
+    current string
+}
+
+// followed returns the topics the client receives.
+func followed() []string {
+    following.Lock()
+    defer following.Unlock()
+    return append([]string{}, following.topics...)
+}
+
+// render formats a message for the terminal, e.g. "[8] Anders: Hello Emil".
+// When following several topics or a pattern like "itu/#" the topic is shown
+// too, as in "[8] #itu Anders: Hello Emil". Notices from the server and
+// changes to the topic stand out from what people say.
+func render(message *chat.Message) string {
+    prefix := fmt.Sprintf("[%d] ", message.Lamport)
+    if topics := followed(); len(topics) > 1 || pattern.HasWildcard(topics[0]) {
+        prefix += "#" + message.Topic + " "
+    }
+    switch p := message.Payload.(type) {
+    case *chat.Message_Chat:
+        return prefix + message.Author + ": " + p.Chat.Text
+    case *chat.Message_Notice:
+        return prefix + "*** " + p.Notice.Text + " ***"
+    case *chat.Message_TopicChanged:
+        return prefix + "~ " + message.Message
+    case nil:
+        // Older servers only send the kind.
+    default:
+        return prefix + message.Message
+    }
+    if message.Kind == chat.EventKind_MESSAGE {
+        return prefix + message.Author + ": " + message.Message
+    }
+    return prefix + message.Message
+}
+
+// stream is the Connect stream the client is on, which print replaces on
+// every reconnect. Every frame sent on it gets a new ref, and acked holds what
+// to do once the server has accepted the frame with that ref.
+var stream struct {
+    sync.Mutex
+    current chat.Chat_ConnectClient
+    ref uint64
+    acked map[uint64]func(ack *chat.MessageAck)
+    // The messages the server has not answered yet, which are sent again
+    // after a reconnect. Their idempotency key keeps them from being
+    // published twice when it was only the answer that got lost.
+    unanswered map[uint64]*chat.ClientFrame
+}
+
+// send sends frame on the current stream, and calls acked once the server
+// has accepted it. A message is also sent while not connected, it just waits
+// for the next connection.
+func send(frame *chat.ClientFrame, acked func(ack *chat.MessageAck)) error {
+    stream.Lock()
+    defer stream.Unlock()
+    stream.ref++
+    frame.Ref = stream.ref
+    if _, ok := frame.Frame.(*chat.ClientFrame_Message); ok {
+        stream.unanswered[frame.Ref] = frame
+    } else if stream.current == nil {
+        return errors.New("not connected")
+    }
+    if acked != nil {
+        stream.acked[frame.Ref] = acked
+    }
+    if stream.current == nil {
+        return nil
+    }
+    return stream.current.Send(frame)
+}
+
+// answered handles the server accepting or rejecting the frame with ref.
+func answered(ref uint64, ack *chat.MessageAck, rejected *chat.Rejected) {
+    stream.Lock()
+    acked := stream.acked[ref]
+    delete(stream.acked, ref)
+    delete(stream.unanswered, ref)
+    stream.Unlock()
+    if rejected != nil && codes.Code(rejected.Code) == codes.ResourceExhausted {
+        wait := time.Duration(rejected.RetryAfter) * time.Millisecond
+        notice(fmt.Sprintf("Slow down! You can send again in %v", wait.Round(100*time.Millisecond)))
+    } else if rejected != nil {
+        notice("Rejected: " + rejected.Reason)
+    } else if acked != nil {
+        acked(ack)
+    }
+}
+
+// lastSent is the id of the last message we sent that the server accepted.
+var lastSent struct {
+    sync.Mutex
+    id string
+}
+
+// seen shows who got and who read the last message we sent.
+func seen(ctx context.Context, client chat.ChatClient, author string) {
+    lastSent.Lock()
+    id := lastSent.id
+    lastSent.Unlock()
+    if id == "" {
+        println("You have not sent anything yet")
+        return
+    }
+    ctx, cancel := call(ctx)
+    defer cancel()
+    list, err := client.Receipts(ctx, &chat.ReceiptQuery{Id: id, Author: author})
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        return
+    }
+    names := func(receipts []*chat.Receipt) string {
+        if len(receipts) == 0 {
+            return "nobody"
+        }
+        var authors []string
+        for _, r := range receipts {
+            authors = append(authors, r.Author)
+        }
+        return strings.Join(authors, ", ")
+    }
+    println("Delivered to:", names(list.Delivered))
+    println("Read by:", names(list.Read))
+}
+
+// access runs the commands that show and change who may use the topic we
+// send to, and reports whether line was one: "/access" shows the list,
+// "/private" and "/open" close and open the topic, and "/member NAME",
+// "/readonly NAME", "/ban NAME", "/remove NAME" and "/owner NAME" give
+// someone a role.
+func access(ctx context.Context, client chat.ChatClient, author string, fields []string) bool {
+    following.Lock()
+    change := &chat.AccessChange{Author: author, Topic: following.current}
+    following.Unlock()
+    switch {
+    case len(fields) == 1 && fields[0] == "/access":
+    case len(fields) == 1 && fields[0] == "/private":
+        change.Visibility = chat.Visibility_PRIVATE
+    case len(fields) == 1 && fields[0] == "/open":
+        change.Visibility = chat.Visibility_OPEN
+    case len(fields) == 2 && fields[0] == "/member":
+        change.Members = fields[1:]
+    case len(fields) == 2 && fields[0] == "/readonly":
+        change.ReadOnly = fields[1:]
+    case len(fields) == 2 && fields[0] == "/ban":
+        change.Banned = fields[1:]
+    case len(fields) == 2 && fields[0] == "/remove":
+        change.Remove = fields[1:]
+    case len(fields) == 2 && fields[0] == "/owner":
+        change.Owner = fields[1]
+    default:
+        return false
+    }
+    ctx, cancel := call(ctx)
+    defer cancel()
+    list, err := client.Access(ctx, change)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        return true
+    }
+    if list.Owner == "" {
+        println("Anyone can use", list.Topic)
+        return true
+    }
+    visibility := "open"
+    if list.Private {
+        visibility = "private"
+    }
+    println(list.Topic, "is", visibility, "and owned by", list.Owner)
+    println("Members:", strings.Join(list.Members, ", "))
+    println("Read-only:", strings.Join(list.ReadOnly, ", "))
+    println("Banned:", strings.Join(list.Banned, ", "))
+    return true
+}
+
+// command runs a line starting with "/" and reports whether it was one:
+// "/join TOPIC" and "/leave TOPIC" change the topics of the stream,
+// "/to TOPIC" sends the next messages to TOPIC and "/seen" shows who got
+// the last message we sent. The commands of access work too.
+func command(ctx context.Context, client chat.ChatClient, author string, line string) bool {
+    fields := strings.Fields(line)
+    if len(fields) == 1 && fields[0] == "/seen" {
+        seen(ctx, client, author)
+        return true
+    }
+    if access(ctx, client, author, fields) {
+        return true
+    }
+    if len(fields) != 2 || !strings.HasPrefix(fields[0], "/") {
+        return false
+    }
+    topic := fields[1]
+    var change *chat.TopicChange
+    switch fields[0] {
+    case "/join":
+        change = &chat.TopicChange{Add: []string{topic}}
+    case "/leave":
+        change = &chat.TopicChange{Remove: []string{topic}}
+    case "/to":
+        following.Lock()
+        following.current = topic
+        following.Unlock()
+        println("Sending to topic:", topic)
+        return true
+    default:
+        return false
+    }
+
+    // The topics only change once the server has agreed.
+    err := send(&chat.ClientFrame{Frame: &chat.ClientFrame_Topics{Topics: change}}, func(*chat.MessageAck) {
+        following.Lock()
+        defer following.Unlock()
+        if len(change.Add) > 0 {
+            // Joining a topic again only makes it the current one.
+            joined := false
+            for _, t := range following.topics {
+                if t == topic {
+                    joined = true
+                    break
+                }
+            }
+            if !joined {
+                following.topics = append(following.topics, topic)
+            }
+            following.current = topic
+        } else {
+            for i, t := range following.topics {
+                if t == topic {
+                    following.topics = append(following.topics[:i], following.topics[i+1:]...)
+                    break
+                }
+            }
+            if following.current == topic {
+                following.current = following.topics[0]
+            }
+        }
+        notice("Sending to topic: " + following.current)
+    })
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+    }
+    return true
+}
+
+// before orders messages by (timestamp, author), which is the same on every client.
+func before(a, b *chat.Message) bool {
+    if a.Lamport != b.Lamport {
+        return a.Lamport < b.Lamport
+    }
+    return a.Author < b.Author
+}
+
+// holdBack keeps every incoming message for window before showing it, and
+// shows the held messages sorted by (timestamp, author). Broadcasts that race
+// each other on the server are then shown in the same order by every client.
+// A message arriving after a later one was already shown can't be put back in
+// its place, so it is shown with late set instead. Closing in flushes the queue.
+func holdBack(window time.Duration, in <-chan *chat.Message, show func(message *chat.Message, late bool)) {
+    type held struct {
+        message *chat.Message
+        due time.Time
+    }
+    var queue []held
+    var last *chat.Message
+    release := func() {
+        message := queue[0].message
+        queue = queue[1:]
+        late := last != nil && before(message, last)
+        if !late {
+            last = message
+        }
+        show(message, late)
+    }
+
+    for {
+        var timeout <-chan time.Time
+        if len(queue) > 0 {
+            timeout = time.After(time.Until(queue[0].due))
+        }
+        select {
+        case message, ok := <-in:
+            if !ok {
+                for len(queue) > 0 {
+                    release()
+                }
+                return
+            }
+            i := sort.Search(len(queue), func(i int) bool { return before(message, queue[i].message) })
+            queue = append(queue, held{})
+            copy(queue[i+1:], queue[i:])
+            queue[i] = held{message: message, due: time.Now().Add(window)}
+        case <-timeout:
+        }
+        for len(queue) > 0 && !time.Now().Before(queue[0].due) {
+            release()
+        }
+    }
+}
+
+// recent holds the last shown messages that carry a vector clock, to find the
+// ones a new message is concurrent with.
+var recent []*chat.Message
+
+// concurrent lists the recent messages that are causally unrelated to message.
+func concurrent(message *chat.Message) []string {
+    var refs []string
+    for _, other := range recent {
+        if vclock.VClock(message.Vector).Compare(other.Vector) == vclock.Concurrent {
+            refs = append(refs, fmt.Sprintf("[%d]", other.Lamport))
+        }
+    }
+    return refs
+}
+
+// sequences is the last sequence number shown on each topic.
+var sequences = map[string]uint64{}
+
+// missing is the range of sequence numbers on the topic that never reached
+// us before message, from first to last, or 0 and 0 when nothing is missing.
+// The server numbers each topic without gaps, and message is only shown once
+// the hold-back window ran out, so a gap left by then means messages were lost.
+func missing(message *chat.Message) (first uint64, last uint64) {
+    shown, found := sequences[message.Topic]
+    if message.Sequence > shown {
+        sequences[message.Topic] = message.Sequence
+    }
+    if !found || message.Sequence <= shown+1 {
+        return 0, 0
+    }
+    return shown + 1, message.Sequence - 1
+}
+
+// notice prints line above the one being typed.
+func notice(line string) {
+    if !interactive {
+        fmt.Println(line)
+        return
+    }
+    fmt.Printf("\r                                                        \r")
+    fmt.Println(line)
+    fmt.Print(string(input))
+}
+
+// typingShown is when each author was last shown to be typing.
+var typingShown = map[string]time.Time{}
+
+// typing shows that someone else is typing, at most every few seconds per author.
+func typing(author string, t *chat.Typing) {
+    if *format == "json" || t.Author == author || time.Since(typingShown[t.Author]) < 3*time.Second {
+        return
+    }
+    typingShown[t.Author] = time.Now()
+    notice(t.Author + " is typing...")
+}
+
+func show(message *chat.Message, late bool) {
+    first, last := missing(message)
+    if *format == "json" {
+        line, err := protojson.Marshal(message)
+        if err == nil {
+            notice(string(line))
+        }
+        return
+    }
+    if first == last && first > 0 {
+        notice(fmt.Sprintf("--- missing #%d on %s ---", first, message.Topic))
+    } else if first > 0 {
+        notice(fmt.Sprintf("--- missing #%d to #%d on %s ---", first, last, message.Topic))
+    }
+    line := render(message)
+    if late {
+        line += " (out of order)"
+    }
+    if len(message.Vector) > 0 {
+        if refs := concurrent(message); len(refs) > 0 {
+            line += " (concurrent with " + strings.Join(refs, ", ") + ")"
+        }
+        recent = append(recent, message)
+        if len(recent) > 20 {
+            recent = recent[1:]
+        }
+    }
+    notice(line)
+}
+
+// receive connects and streams the followed topics until the stream breaks,
+// and returns why once every message received has been shown. resume is the
+// last sequence number already shown on each topic, which is empty on the
+// first connection.
+func receive(ctx context.Context, client chat.ChatClient, author string, resume map[string]uint64) error {
+    topics := followed()
+    conn, err := client.Connect(ctx, grpc.WaitForReady(true))
+    if err != nil {
+        return err
+    }
+    defer conn.CloseSend()
+    hello := &chat.Request{Author: author, Topic: topics[0], Topics: topics[1:], Lamport: clock.Tick(), ReplayLast: uint32(*replay), Resume: resume}
+    if err := conn.Send(&chat.ClientFrame{Frame: &chat.ClientFrame_Hello{Hello: hello}}); err != nil {
+        return err
+    }
+    stream.Lock()
+    stream.current = conn
+    // Only the messages are sent again, so only they can still be answered.
+    for ref := range stream.acked {
+        if stream.unanswered[ref] == nil {
+            delete(stream.acked, ref)
+        }
+    }
+    var refs []uint64
+    for ref := range stream.unanswered {
+        refs = append(refs, ref)
+    }
+    sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+    for _, ref := range refs {
+        conn.Send(stream.unanswered[ref])
+    }
+    stream.Unlock()
+    defer func() {
+        stream.Lock()
+        stream.current = nil
+        stream.Unlock()
+    }()
+
+    messages := make(chan *chat.Message)
+    done := make(chan struct{})
+    go func() {
+        holdBack(*window, messages, func(message *chat.Message, late bool) {
+            show(message, late)
+            if *readReceipts && message.Kind == chat.EventKind_MESSAGE && message.Author != author {
+                send(&chat.ClientFrame{Frame: &chat.ClientFrame_Read{Read: &chat.Receipt{Id: message.Id, Author: author}}}, nil)
+            }
+        })
+        close(done)
+    }()
+    defer func() {
+        close(messages)
+        <-done
+    }()
+    for {
+        frame, err := conn.Recv()
+        if err != nil {
+            return err
+        }
+        switch f := frame.Frame.(type) {
+        case *chat.ServerFrame_Message:
+            clock.Merge(f.Message.Lamport)
+            messages <- f.Message
+        case *chat.ServerFrame_Ack:
+            answered(frame.Ref, f.Ack, nil)
+        case *chat.ServerFrame_Rejected:
+            answered(frame.Ref, nil, f.Rejected)
+        case *chat.ServerFrame_Typing:
+            typing(author, f.Typing)
+        }
+   }
+}
+
+// call returns the context for a call to the server, which gives up after -timeout.
+func call(ctx context.Context) (context.Context, context.CancelFunc) {
+    if *timeout > 0 {
+        return context.WithTimeout(ctx, *timeout)
+    }
+    return context.WithCancel(ctx)
+}
+
+// token is sent with every call once we have logged in.
+var token auth.Token
+
+// login gets a new token if the client has a password.
+func login(ctx context.Context, client chat.ChatClient, author string) error {
+    if *password == "" {
+        return nil
+    }
+    ctx, cancel := call(ctx)
+    defer cancel()
+    t, err := client.Login(ctx, &chat.Credentials{Author: author, Password: *password}, grpc.WaitForReady(true))
+    if err != nil {
+        return err
+    }
+    token.Set(t.Token)
+    return nil
+}
+
+// permanent reports whether err comes back however often we reconnect, like
+// an invalid topic, a topic we may not read or a wrong password.
+func permanent(err error) bool {
+    switch status.Code(err) {
+    case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition:
+        return true
+    }
+    return false
+}
+
+// print shows the messages on the followed topics, and when the connection
+// breaks it reconnects and resumes after the last messages it showed. It
+// only returns on an error that reconnecting can't fix, or when ctx is done.
+func print(ctx context.Context, client chat.ChatClient, author string) error {
+    for {
+        // The token may have expired while we were connected.
+        err := login(ctx, client, author)
+        if err == nil {
+            resume := map[string]uint64{}
+            for topic, sequence := range sequences {
+                resume[topic] = sequence
+            }
+            err = receive(ctx, client, author, resume)
+        }
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        if permanent(err) {
+            return err
+        }
+        println("Connection lost, reconnecting...")
+        time.Sleep(time.Second)
+    }
+}
+
+// sendOnce sends one message for the send command and returns the exit
+// status. Without text it sends what it reads from standard input.
+func sendOnce(ctx context.Context, client chat.ChatClient, author string, topic string, text string) int {
+    if text == "" {
+        in, err := io.ReadAll(os.Stdin)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, "Error:", err)
+            return 1
+        }
+        text = strings.TrimRight(string(in), "\n")
+    }
+    if err := login(ctx, client, author); err != nil {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        return 1
+    }
+    key := make([]byte, 8)
+    rand.Read(key)
+    message := &chat.Message{Author: author, Topic: topic, Message: text, Lamport: clock.Tick(), IdempotencyKey: hex.EncodeToString(key)}
+
+    ctx, cancel := call(ctx)
+    defer cancel()
+    var trailer metadata.MD
+    ack, err := client.Send(ctx, message, grpc.WaitForReady(true), grpc.Trailer(&trailer))
+    if retry := trailer.Get("retry-after"); status.Code(err) == codes.ResourceExhausted && len(retry) > 0 {
+        fmt.Fprintf(os.Stderr, "Slow down! You can send again in %ss\n", retry[0])
+        return 1
+    }
+    if status.Code(err) == codes.NotFound {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        fmt.Fprintf(os.Stderr, "Nobody has joined %s yet. Start a chat or tail on it first, e.g. go run ./client tail %s %s\n", topic, author, topic)
+        return 1
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+        return 1
+    }
+    if *format == "json" {
+        line, _ := protojson.Marshal(ack)
+        fmt.Println(string(line))
+    } else {
+        fmt.Printf("Sent %s at [%d] to %d subscriber(s)\n", ack.Id, ack.Lamport, ack.Subscribers)
+    }
+    return 0
+}
+
+// dial connects to -server, with TLS if any of the TLS flags are given.
+func dial() (*grpc.ClientConn, error) {
+    var opts []grpc.DialOption
+    opts = append(opts, grpc.WithBlock(), grpc.WithPerRPCCredentials(&token))
+    if *useTLS || *caFile != "" || *certFile != "" {
+        config, err := auth.ClientTLS(*caFile, *certFile, *keyFile)
+        if err != nil {
+            return nil, err
+        }
+        opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+    } else {
+        opts = append(opts, grpc.WithInsecure())
+    }
+    ctx, cancel := call(context.Background())
+    defer cancel()
+    return grpc.DialContext(ctx, *server, opts...)
+}
+
+// usageError explains what is wrong with the command line and exits.
+func usageError(problem string) {
+    fmt.Fprintln(os.Stderr, problem)
+    flag.Usage()
+    os.Exit(2)
+}
+
+func main() {
+    flag.Usage = func() {
+        fmt.Fprint(flag.CommandLine.Output(), usage)
+        flag.PrintDefaults()
+    }
+    mode := "chat"
+    if len(os.Args) > 1 && (os.Args[1] == "send" || os.Args[1] == "tail") {
+        mode = os.Args[1]
+        flag.CommandLine.Parse(os.Args[2:])
+    } else {
+        flag.Parse()
+    }
+    if *format != "text" && *format != "json" {
+        usageError("unknown format: " + *format)
+    }
+
+    args := flag.Args()
+    author := *authorFlag
+    if author == "" && len(args) > 0 {
+        author, args = args[0], args[1:]
+    }
+    topics := topicFlag.list
+    if len(topics) == 0 && len(args) > 0 {
+        if mode == "send" {
+            topics, args = args[:1], args[1:]
+        } else {
+            topics, args = args, nil
+        }
+    }
+    switch {
+    case author == "":
+        usageError("no name given")
+    case len(topics) == 0:
+        usageError("no topic given")
+    case mode == "send" && len(topics) > 1:
+        usageError("send sends to one topic")
+    case mode != "send" && len(args) > 0:
+        usageError("unexpected arguments: " + strings.Join(args, " "))
+    }
+    following.topics = topics
+    following.current = topics[0]
+    stream.acked = map[uint64]func(ack *chat.MessageAck){}
+    stream.unanswered = map[uint64]*chat.ClientFrame{}
+
+    conn, err := dial()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "did not connect:", err)
+        os.Exit(1)
+    }
+    defer conn.Close()
+    ctx := context.Background()
+    client := chat.NewChatClient(conn)
+
+    switch mode {
+    case "send":
+        status := sendOnce(ctx, client, author, topics[0], strings.Join(args, " "))
+        conn.Close()
+        os.Exit(status)
+    case "tail":
+        failed := make(chan error, 1)
+        go func() {
+            failed <- print(ctx, client, author)
+        }()
+        quit := make(chan os.Signal, 1)
+        signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+        select {
+        case <-quit:
+            return
+        case <-failed:
+            conn.Close()
+            os.Exit(1)
+        }
+    }
+
+    interactive = true
+    input = append(input, 0x3e)
+    input = append(input, 0x3e)
+    input = append(input, 0x3e)
+    input = append(input, 0x20)
+    println("Starting client")
+    println("Joining as user:", author)
+    println("To topic:", strings.Join(following.topics, ", "))
+    println()
+    fmt.Print(string(input))
+
+    go func() {
+        // print only returns on an error reconnecting can't fix.
+        print(ctx, client, author)
+        exec.Command("stty", "-F", "/dev/tty", "sane").Run()
+        os.Exit(1)
+    }()
+
+    reader := bufio.NewReader(os.Stdin)
+    exec.Command("stty", "-F", "/dev/tty", "cbreak", "min", "1").Run()
+    for {
+        b, err := reader.ReadByte()
+        if err != nil {
+            // standard input was closed
+            return
+        }
+        if b == 0x0A { // send on enter
+            // send data
+            fmt.Printf("\r                                                        \r")
+            line := string(input[4:])
+            if strings.TrimSpace(line) == "" {
+                // nothing to send
+            } else if !command(ctx, client, author, line) {
+                following.Lock()
+                topic := following.current
+                following.Unlock()
+                key := make([]byte, 8)
+                rand.Read(key)
+                message := &chat.Message{Author: author, Topic: topic, Message: line, Lamport: clock.Tick(), IdempotencyKey: hex.EncodeToString(key)}
+                err := send(&chat.ClientFrame{Frame: &chat.ClientFrame_Message{Message: message}}, func(ack *chat.MessageAck) {
+                    lastSent.Lock()
+                    lastSent.id = ack.Id
+                    lastSent.Unlock()
+                })
+                if err != nil {
+                    fmt.Fprintln(os.Stderr, "Error:", status.Convert(err).Message())
+                }
+            }
+            input = input[:4]
+        } else {
+        if len(input) == 4 { // tell the others on the first key of a line
+            following.Lock()
+            topic := following.current
+            following.Unlock()
+            send(&chat.ClientFrame{Frame: &chat.ClientFrame_Typing{Typing: &chat.Typing{Author: author, Topic: topic}}}, nil)
+        }
+        input = append(input, b)
+        }
+    }
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+    "fmt"
+    "testing"
+    "time"
+
+    chat "github.com/AndersStendevad/disys-m3/grpc"
+)
+
+func TestHoldBack(t *testing.T) {
+    const window = 20 * time.Millisecond
+    type in struct {
+        lamport uint64
+        author string
+        // pause waits until everything before was shown.
+        pause bool
+    }
+    for _, c := range []struct {
+        name string
+        window time.Duration
+        in []in
+        // want is how the messages are shown, with "late" after a message
+        // shown out of order.
+        want string
+    }{
+        {"in order", window, []in{{1, "Anders", false}, {2, "Emil", false}}, "[1 Anders 2 Emil]"},
+        {"sorted by timestamp", window, []in{{3, "Anders", false}, {1, "Emil", false}, {2, "Sebastian", false}}, "[1 Emil 2 Sebastian 3 Anders]"},
+        {"author breaks ties", window, []in{{5, "Sebastian", false}, {5, "Anders", false}, {5, "Emil", false}}, "[5 Anders 5 Emil 5 Sebastian]"},
+        {"late", window, []in{{5, "Anders", false}, {3, "Emil", true}, {6, "Emil", false}}, "[5 Anders 3 Emil late 6 Emil]"},
+        {"late is not the last shown", window, []in{{5, "Anders", false}, {3, "Emil", true}, {4, "Emil", true}}, "[5 Anders 3 Emil late 4 Emil late]"},
+        {"closing flushes", time.Hour, []in{{2, "Emil", false}, {1, "Anders", false}}, "[1 Anders 2 Emil]"},
+    } {
+        messages := make(chan *chat.Message)
+        var shown []interface{}
+        done := make(chan struct{})
+        go func() {
+            holdBack(c.window, messages, func(message *chat.Message, late bool) {
+                shown = append(shown, message.Lamport, message.Author)
+                if late {
+                    shown = append(shown, "late")
+                }
+            })
+            close(done)
+        }()
+        for _, m := range c.in {
+            if m.pause {
+                time.Sleep(5 * window)
+            }
+            messages <- &chat.Message{Lamport: m.lamport, Author: m.author}
+        }
+        close(messages)
+        select {
+        case <-done:
+        case <-time.After(time.Second):
+            t.Fatalf("%s: closing did not flush the queue", c.name)
+        }
+        if got := fmt.Sprint(shown); got != c.want {
+            t.Errorf("%s: shown %s, want %s", c.name, got, c.want)
+        }
+    }
+}
+
+func TestMissing(t *testing.T) {
+    type in struct {
+        topic string
+        sequence uint64
+        // first and last is the range reported missing before the message.
+        first, last uint64
+    }
+    for _, c := range []struct {
+        name string
+        in []in
+    }{
+        {"first message on a topic", []in{{"itu", 7, 0, 0}}},
+        {"no gap", []in{{"itu", 1, 0, 0}, {"itu", 2, 0, 0}}},
+        {"single number", []in{{"itu", 1, 0, 0}, {"itu", 3, 2, 2}}},
+        {"range", []in{{"itu", 1, 0, 0}, {"itu", 5, 2, 4}}},
+        {"each topic on its own", []in{{"itu", 1, 0, 0}, {"dtu", 4, 0, 0}, {"itu", 2, 0, 0}, {"dtu", 6, 5, 5}}},
+        {"late message after a gap", []in{{"itu", 1, 0, 0}, {"itu", 3, 2, 2}, {"itu", 2, 0, 0}, {"itu", 4, 0, 0}}},
+    } {
+        sequences = map[string]uint64{}
+        for i, m := range c.in {
+            first, last := missing(&chat.Message{Topic: m.topic, Sequence: m.sequence})
+            if first != m.first || last != m.last {
+                t.Errorf("%s: message %d (%s#%d) is missing #%d to #%d, want #%d to #%d", c.name, i, m.topic, m.sequence, first, last, m.first, m.last)
+            }
+        }
+    }
+}
+
+func TestClock(t *testing.T) {
+    for _, c := range []struct {
+        name string
+        // steps are ticks for 0 and merges of the timestamp otherwise.
+        steps []uint64
+        want []uint64
+    }{
+        {"ticks", []uint64{0, 0, 0}, []uint64{1, 2, 3}},
+        {"merge of a later time", []uint64{0, 10, 0}, []uint64{1, 11, 12}},
+        {"merge of an earlier time", []uint64{0, 0, 0, 1}, []uint64{1, 2, 3, 4}},
+        {"merge of the same time", []uint64{0, 0, 2}, []uint64{1, 2, 3}},
+    } {
+        var clock Clock
+        var got []uint64
+        for _, step := range c.steps {
+            if step == 0 {
+                got = append(got, clock.Tick())
+            } else {
+                got = append(got, clock.Merge(step))
+            }
+        }
+        if fmt.Sprint(got) != fmt.Sprint(c.want) {
+            t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+        }
+    }
+}
@@ -0,0 +1,788 @@
+// Package eventbus broadcasts chat events to the subscribers of a topic.
+// Every subscriber has its own queue, so a slow one never holds up the
+// others, and the bus stamps every event it publishes with a Lamport
+// timestamp and a sequence number per topic.
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AndersStendevad/disys-m3/pattern"
+	"github.com/AndersStendevad/disys-m3/vclock"
+)
+
+// MessageEvent is one event on a topic.
+type MessageEvent struct {
+	Payload Payload
+	Topic   string
+	Author  string
+	Vector  vclock.VClock
+	// Lamport is the timestamp the bus stamped the event with. On the way
+	// into Publish it is the sender's clock instead.
+	Lamport int
+	// Sequence numbers the events of one topic 1, 2, 3, ... without gaps.
+	Sequence int
+}
+
+// payload is the payload of the event. An event without one is an empty
+// chat message, so a zero MessageEvent can't break a stream.
+func (d MessageEvent) payload() Payload {
+	if d.Payload == nil {
+		return Chat{}
+	}
+	return d.Payload
+}
+
+// Text is the event as it is shown in the chat, e.g. "Anders: Hello" or "Anders joined".
+func (d MessageEvent) Text() string {
+	return d.payload().text(d.Author)
+}
+
+// ID is the id the server gives the event, e.g. "itu#42" for the 42nd event on
+// itu. A topic can't contain "#", so the id always tells which topic it is on.
+func (d MessageEvent) ID() string {
+	return d.Topic + "#" + strconv.Itoa(d.Sequence)
+}
+
+type DataChannel chan MessageEvent
+
+// Client is who reads the events sent to a channel.
+type Client struct {
+	Author string
+	// Lamport is the clock of the client, which the bus takes in when it
+	// subscribes or unsubscribes, like a message it received.
+	Lamport int
+	// Replaying holds off the overflow policy while the client is sent the
+	// history, so the live events that come in meanwhile all wait in the
+	// queue until Live is called. It only counts when the channel is
+	// subscribed for the first time.
+	Replaying bool
+}
+
+// Replay is the history a subscriber asks for. With After it resumes after
+// that sequence number on the topic. Otherwise the whole history is narrowed
+// down to the events stamped after Since, and of those only the last Last.
+// The zero Replay asks for nothing.
+type Replay struct {
+	After int
+	Since int
+	Last  int
+}
+
+// Log is where the bus keeps every event it publishes, so they can be
+// replayed after they were dropped from memory or the bus was restarted.
+type Log interface {
+	// Append adds d to the end of its topic.
+	Append(d MessageEvent) error
+	// Read returns every event on topic, oldest first.
+	Read(topic string) ([]MessageEvent, error)
+	// Topics lists every topic in the log.
+	Topics() ([]string, error)
+}
+
+// Overflow is what happens when an event is published to a subscriber whose queue is full.
+type Overflow int
+
+const (
+	DropOldest Overflow = iota
+	DropNewest
+	Disconnect
+)
+
+var overflows = map[string]Overflow{
+	"drop-oldest": DropOldest,
+	"drop-newest": DropNewest,
+	"disconnect":  Disconnect,
+}
+
+// ParseOverflow returns the policy called name: drop-oldest, drop-newest or disconnect.
+func ParseOverflow(name string) (Overflow, error) {
+	policy, found := overflows[name]
+	if !found {
+		return 0, fmt.Errorf("unknown overflow policy: %v", name)
+	}
+	return policy, nil
+}
+
+// ErrStopped is returned when subscribing a channel whose feed was stopped,
+// because it fell behind with the disconnect policy or the bus was closed.
+// The channel is closed, or will be shortly, and can't be subscribed again.
+var ErrStopped = errors.New("the channel was stopped")
+
+// ErrClosed is returned when subscribing after the bus was closed.
+var ErrClosed = errors.New("the bus is closed")
+
+// feed is the channel of one Receive stream, which can follow several
+// topics. Every queue of the feed has a writer goroutine sending to the
+// channel, and the channel is closed once the feed is stopped and all of
+// its writers are done.
+type feed struct {
+	ch     DataChannel
+	client Client
+	log    *log.Logger
+	// topics counts the topics ch is subscribed to.
+	topics int
+	// In total-order mode all topics of the feed share this one queue.
+	shared *subscriber
+	// replaying is set until Live is called for a client that is sent the
+	// history first. Nothing is dropped from its queues meanwhile. It is
+	// only changed while holding the bus lock for writing.
+	replaying bool
+	// dropped counts what the queues that were taken out of subs dropped.
+	// It is only changed while holding the bus lock for writing.
+	dropped int
+	// subs is every queue of the feed that is not stopped.
+	subs    map[*subscriber]bool
+	writers sync.WaitGroup
+	quit    chan struct{}
+	once    sync.Once
+}
+
+func newFeed(ch DataChannel, client Client, logger *log.Logger) *feed {
+	return &feed{ch: ch, client: client, log: logger, replaying: client.Replaying, subs: map[*subscriber]bool{}, quit: make(chan struct{})}
+}
+
+// stopped reports whether stop was called.
+func (f *feed) stopped() bool {
+	select {
+	case <-f.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// logf logs to the logger of the bus, if it has one.
+func (f *feed) logf(format string, args ...interface{}) {
+	if f.log != nil {
+		f.log.Printf(format, args...)
+	}
+}
+
+// stop ends every writer of the feed and then closes the channel.
+func (f *feed) stop() {
+	f.once.Do(func() {
+		close(f.quit)
+		go func() {
+			f.writers.Wait()
+			close(f.ch)
+		}()
+	})
+}
+
+// subscriber is a queue of events waiting to be sent to a feed. Publish only
+// puts events in the queue, and a writer goroutine per subscriber moves them
+// on to the channel, so a slow client never holds up delivery to anybody else.
+type subscriber struct {
+	feed     *feed
+	mu       sync.Mutex
+	queue    []MessageEvent
+	limit    int
+	overflow Overflow
+	// dropped counts the events this subscriber lost to a full queue.
+	dropped int
+	// busy is set while the writer hands an event it took from the queue to the feed.
+	busy bool
+	// grace is how many of the events at the front of the queue were there
+	// when the feed went live. The limit only counts the events after them,
+	// and nothing is dropped from them.
+	grace   int
+	stopped bool
+	wake    chan struct{}
+	quit    chan struct{}
+}
+
+func newSubscriber(f *feed, limit int, overflow Overflow) *subscriber {
+	sub := &subscriber{
+		feed:     f,
+		limit:    limit,
+		overflow: overflow,
+		wake:     make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	f.subs[sub] = true
+	f.writers.Add(1)
+	go sub.write()
+	return sub
+}
+
+// push queues data for the subscriber without ever blocking.
+func (sub *subscriber) push(data MessageEvent) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.stopped {
+		return
+	}
+	if len(sub.queue) >= sub.limit+sub.grace && !sub.feed.replaying {
+		sub.dropped++
+		sub.feed.logf("time: %d queue full for %s, dropped so far: %d", data.Lamport, sub.feed.client.Author, sub.dropped)
+		switch sub.overflow {
+		case DropOldest:
+			sub.queue = append(sub.queue[:sub.grace], sub.queue[sub.grace+1:]...)
+		case DropNewest:
+			return
+		case Disconnect:
+			sub.queue = nil
+			sub.stop()
+			sub.feed.stop()
+			return
+		}
+	}
+	sub.queue = append(sub.queue, data)
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the writer and reports what was dropped. The caller holds sub.mu.
+func (sub *subscriber) stop() {
+	if !sub.stopped {
+		sub.stopped = true
+		close(sub.quit)
+		if sub.dropped > 0 {
+			sub.feed.logf("dropped %d messages for %s", sub.dropped, sub.feed.client.Author)
+		}
+	}
+}
+
+// write moves queued events to the channel one at a time, oldest first.
+func (sub *subscriber) write() {
+	defer sub.feed.writers.Done()
+	for {
+		sub.mu.Lock()
+		if len(sub.queue) == 0 {
+			sub.mu.Unlock()
+			select {
+			case <-sub.wake:
+				continue
+			case <-sub.quit:
+				return
+			case <-sub.feed.quit:
+				return
+			}
+		}
+		data := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		if sub.grace > 0 {
+			sub.grace--
+		}
+		sub.busy = true
+		sub.mu.Unlock()
+		select {
+		case sub.feed.ch <- data:
+		case <-sub.quit:
+			return
+		case <-sub.feed.quit:
+			return
+		}
+		sub.mu.Lock()
+		sub.busy = false
+		sub.mu.Unlock()
+	}
+}
+
+// drained reports whether the subscriber has handed every event to its feed.
+func (sub *subscriber) drained() bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.stopped || (len(sub.queue) == 0 && !sub.busy)
+}
+
+// Bus is what a service needs to broadcast events to subscribers.
+type Bus interface {
+	// Subscribe sends every event published on topic from now on to ch, and
+	// returns the last sequence number on the topic.
+	Subscribe(topic string, ch DataChannel, client Client) (int, error)
+	// Unsubscribe stops sending the events on topic to ch.
+	Unsubscribe(topic string, ch DataChannel, client Client)
+	// Publish stamps data and broadcasts it on its topic.
+	Publish(data MessageEvent) (MessageEvent, int)
+	// Close stops every subscriber.
+	Close()
+}
+
+var _ Bus = (*EventBus)(nil)
+
+// Config is how an EventBus behaves. The zero value is a bus that queues
+// one event per subscriber and retains nothing.
+type Config struct {
+	// Vector stamps events with a vector clock that has an entry per author.
+	Vector bool
+	// Retain is the number of events kept in memory per topic for
+	// subscribers that come back.
+	Retain int
+	// TotalOrder delivers all topics a channel follows merged in one global order.
+	TotalOrder bool
+	// Queue is the number of events that can wait for each subscriber, and
+	// Overflow what happens when more come in.
+	Queue    int
+	Overflow Overflow
+	// Log is where every published event is appended, if it is not nil.
+	Log Log
+	// Logger gets a line for every subscriber, event and dropped event. A
+	// nil Logger logs nothing.
+	Logger *log.Logger
+}
+
+// EventBus is a Bus that retains, logs and replays events. It is safe for
+// concurrent use.
+type EventBus struct {
+	// The subscribers of every topic or pattern like "itu/#".
+	subscribers       *pattern.Trie[*subscriber]
+	feeds             map[DataChannel]*feed
+	rm                sync.RWMutex
+	lamport_timestamp int
+	// The last sequence number given out on each topic.
+	sequences map[string]int
+	// In vector mode every author has a vector clock, which ticks when the
+	// author publishes and takes in the clock of every event delivered to them.
+	vector  bool
+	vectors map[string]vclock.VClock
+	// Every published event is appended to the log, when there is one.
+	log Log
+	// logger gets what happens on the bus, when there is one.
+	logger *log.Logger
+	// The last events on each topic are kept in memory for clients that
+	// reconnect, so they can get what they missed without a log.
+	retained map[string][]MessageEvent
+	// speakers is who has said something on each topic: the one author
+	// that has, or "" once more than one has.
+	speakers map[string]string
+	retain   int
+	// Every subscriber gets a queue of this many events, and overflow decides
+	// what happens when it is full.
+	queue    int
+	overflow Overflow
+	// In total-order mode the topics a feed follows share one queue. Every
+	// event is queued under the lock, which makes the lock a single sequencer,
+	// so the feed gets all its topics merged in the global Lamport order.
+	totalOrder bool
+	// Once closed every feed is stopped, and nothing can subscribe any more.
+	closed bool
+}
+
+// Restore continues the clocks from where the log ends, so sequence numbers
+// and timestamps keep increasing across restarts of the server.
+func (eb *EventBus) Restore() error {
+	if eb.log == nil {
+		return nil
+	}
+	topics, err := eb.log.Topics()
+	if err != nil {
+		return err
+	}
+	eb.rm.Lock()
+	defer eb.rm.Unlock()
+	for _, topic := range topics {
+		events, err := eb.log.Read(topic)
+		if err != nil {
+			return err
+		}
+		for _, d := range events {
+			eb.sequences[topic] = d.Sequence
+			if d.Lamport > eb.lamport_timestamp {
+				eb.lamport_timestamp = d.Lamport
+			}
+			if eb.vector && d.Vector != nil {
+				eb.vectorOf(d.Author).Merge(d.Vector)
+			}
+			eb.spoke(d)
+		}
+	}
+	return nil
+}
+
+// events returns the events on topic numbered after+1 up to and including
+// upto. They come from the retained events when those reach back far enough,
+// and from the log otherwise.
+func (eb *EventBus) events(topic string, after int, upto int) ([]MessageEvent, error) {
+	var events []MessageEvent
+	eb.rm.RLock()
+	retained := eb.retained[topic]
+	covered := eb.log == nil || (len(retained) > 0 && retained[0].Sequence <= after+1)
+	if covered {
+		for _, d := range retained {
+			if d.Sequence > after && d.Sequence <= upto {
+				events = append(events, d)
+			}
+		}
+	}
+	eb.rm.RUnlock()
+	if covered {
+		return events, nil
+	}
+
+	logged, err := eb.log.Read(topic)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range logged {
+		if d.Sequence > after && d.Sequence <= upto {
+			events = append(events, d)
+		}
+	}
+	return events, nil
+}
+
+// History returns the events on topic that replay asks for, which a
+// subscriber gets before the live ones that start after upto. A client
+// resuming after the last sequence number it saw on the topic gets
+// everything it missed since then.
+func (eb *EventBus) History(topic string, upto int, replay Replay) ([]MessageEvent, error) {
+	if replay.After > 0 {
+		return eb.events(topic, replay.After, upto)
+	}
+	if replay.Last == 0 && replay.Since == 0 {
+		return nil, nil
+	}
+	events, err := eb.events(topic, 0, upto)
+	if err != nil {
+		return nil, err
+	}
+	var history []MessageEvent
+	for _, d := range events {
+		if d.Lamport > replay.Since {
+			history = append(history, d)
+		}
+	}
+	if replay.Last > 0 && len(history) > replay.Last {
+		history = history[len(history)-replay.Last:]
+	}
+	return history, nil
+}
+
+// vectorOf returns the vector clock of author. The caller holds the lock.
+func (eb *EventBus) vectorOf(author string) vclock.VClock {
+	v, found := eb.vectors[author]
+	if !found {
+		v = vclock.VClock{}
+		eb.vectors[author] = v
+	}
+	return v
+}
+
+// Delivered records that data reached author, so whatever author publishes
+// next happened after it. It only does something in vector mode.
+func (eb *EventBus) Delivered(author string, data MessageEvent) {
+	if !eb.vector || data.Vector == nil {
+		return
+	}
+	eb.rm.Lock()
+	eb.vectorOf(author).Merge(data.Vector)
+	eb.rm.Unlock()
+}
+
+// logf logs to the logger, if the bus has one.
+func (eb *EventBus) logf(format string, args ...interface{}) {
+	if eb.logger != nil {
+		eb.logger.Printf(format, args...)
+	}
+}
+
+// witness moves the clock past a timestamp received from a client, like any
+// Lamport receive event: max(local, received) + 1. The caller holds the lock.
+func (eb *EventBus) witness(received int) {
+	if received > eb.lamport_timestamp {
+		eb.lamport_timestamp = received
+	}
+	eb.lamport_timestamp++
+}
+
+// Subscribe adds ch to the subscribers of topic and returns the last sequence
+// number on the topic. Every event after that one is sent to ch. The same ch
+// can be subscribed to several topics.
+func (eb *EventBus) Subscribe(topic string, ch DataChannel, client Client) (int, error) {
+	seen, err := eb.SubscribeAll([]string{topic}, ch, client)
+	return seen[topic], err
+}
+
+// SubscribeAll subscribes ch to all of topics at once and returns the last
+// sequence number on each of them. Nothing is published in between, so every
+// event up to those numbers happened before every event sent to ch. A topic
+// can be a pattern like "itu/*" or "itu/#", and then the sequence numbers of
+// every topic it matches so far are returned.
+//
+// Once the bus is closed it returns ErrClosed, and ErrStopped for a channel
+// that was stopped, without subscribing anything.
+func (eb *EventBus) SubscribeAll(topics []string, ch DataChannel, client Client) (map[string]int, error) {
+	eb.rm.Lock()
+	defer eb.rm.Unlock()
+	f, found := eb.feeds[ch]
+	if eb.closed {
+		return nil, ErrClosed
+	}
+	if found && f.stopped() {
+		return nil, ErrStopped
+	}
+	eb.witness(client.Lamport)
+	eb.logf("time: %d subscribed %s to %v", eb.lamport_timestamp, client.Author, topics)
+	if !found {
+		f = newFeed(ch, client, eb.logger)
+		eb.feeds[ch] = f
+	}
+	seen := map[string]int{}
+	for _, topic := range topics {
+		f.topics++
+		var sub *subscriber
+		if eb.totalOrder {
+			if f.shared == nil {
+				f.shared = newSubscriber(f, eb.queue, eb.overflow)
+			}
+			sub = f.shared
+		} else {
+			sub = newSubscriber(f, eb.queue, eb.overflow)
+		}
+		eb.subscribers.Add(topic, sub)
+		if !pattern.HasWildcard(topic) {
+			seen[topic] = eb.sequences[topic]
+			continue
+		}
+		for t, sequence := range eb.sequences {
+			if pattern.Match(topic, t) {
+				seen[t] = sequence
+			}
+		}
+	}
+	return seen, nil
+}
+
+// Live ends the replay of a channel that was subscribed with
+// Client.Replaying. The events that queued up meanwhile are all still sent,
+// and from now on the overflow policy applies to what comes in after them.
+func (eb *EventBus) Live(ch DataChannel) {
+	eb.rm.Lock()
+	defer eb.rm.Unlock()
+	f, found := eb.feeds[ch]
+	if !found || !f.replaying {
+		return
+	}
+	f.replaying = false
+	for sub := range f.subs {
+		sub.mu.Lock()
+		sub.grace = len(sub.queue)
+		sub.mu.Unlock()
+	}
+}
+
+// Dropped returns how many events the queues of ch dropped because they were
+// full, until ch is unsubscribed from its last topic.
+func (eb *EventBus) Dropped(ch DataChannel) int {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+	f, found := eb.feeds[ch]
+	if !found {
+		return 0
+	}
+	dropped := f.dropped
+	for sub := range f.subs {
+		sub.mu.Lock()
+		dropped += sub.dropped
+		sub.mu.Unlock()
+	}
+	return dropped
+}
+
+// Unsubscribe removes ch from the subscribers of topic. When ch follows no
+// topics any more it is closed.
+func (eb *EventBus) Unsubscribe(topic string, ch DataChannel, client Client) {
+	eb.rm.Lock()
+	eb.witness(client.Lamport)
+	eb.logf("time: %d unsubscribed %s from %s", eb.lamport_timestamp, client.Author, topic)
+	for _, sub := range eb.subscribers.Values(topic) {
+		if sub.feed.ch == ch {
+			eb.subscribers.Remove(topic, sub)
+			f := sub.feed
+			f.topics--
+			if f.shared == nil || f.topics == 0 {
+				sub.mu.Lock()
+				sub.stop()
+				f.dropped += sub.dropped
+				sub.mu.Unlock()
+				delete(f.subs, sub)
+			}
+			if f.topics == 0 {
+				f.stop()
+				delete(eb.feeds, ch)
+			}
+			break
+		}
+	}
+	eb.rm.Unlock()
+}
+
+// Publish stamps data with the bus clock and broadcasts it on data.Topic.
+// On the way in data.Lamport is the sender's clock, which is merged first.
+// It returns the event as it was stamped and how many subscribers got it.
+func (eb *EventBus) Publish(data MessageEvent) (MessageEvent, int) {
+	eb.rm.Lock()
+	eb.witness(data.Lamport)
+	eb.logf("time: %d received on %s: %s", eb.lamport_timestamp, data.Topic, data.Text())
+	eb.lamport_timestamp++
+	eb.logf("time: %d broadcast to subscribers", eb.lamport_timestamp)
+	data.Lamport = eb.lamport_timestamp
+	eb.sequences[data.Topic]++
+	data.Sequence = eb.sequences[data.Topic]
+	if eb.vector {
+		v := eb.vectorOf(data.Author)
+		v.Tick(data.Author)
+		data.Vector = v.Copy()
+	}
+	eb.spoke(data)
+	eb.retained[data.Topic] = append(eb.retained[data.Topic], data)
+	if n := len(eb.retained[data.Topic]); n > eb.retain {
+		eb.retained[data.Topic] = eb.retained[data.Topic][n-eb.retain:]
+	}
+	if eb.log != nil {
+		if err := eb.log.Append(data); err != nil {
+			eb.logf("time: %d failed to log message: %v", eb.lamport_timestamp, err)
+		}
+	}
+	// A channel that follows several patterns matching the topic gets the event once.
+	pushed := map[*feed]bool{}
+	for _, sub := range eb.subscribers.Match(data.Topic) {
+		if !pushed[sub.feed] {
+			pushed[sub.feed] = true
+			sub.push(data)
+		}
+	}
+	eb.rm.Unlock()
+	return data, len(pushed)
+}
+
+// spoke notes who said something on the topic of d. The caller holds the lock.
+func (eb *EventBus) spoke(d MessageEvent) {
+	if _, ok := d.Payload.(Chat); !ok {
+		return
+	}
+	if speaker, found := eb.speakers[d.Topic]; !found {
+		eb.speakers[d.Topic] = d.Author
+	} else if speaker != d.Author {
+		eb.speakers[d.Topic] = ""
+	}
+}
+
+// Used reports whether anyone but author follows topic or has said
+// something on it, not counting patterns.
+func (eb *EventBus) Used(topic string, author string) bool {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+	if speaker, found := eb.speakers[topic]; found && speaker != author {
+		return true
+	}
+	for _, sub := range eb.subscribers.Values(topic) {
+		if sub.feed.client.Author != author {
+			return true
+		}
+	}
+	return false
+}
+
+// Known reports whether anything was ever published on topic or anyone follows it.
+func (eb *EventBus) Known(topic string) bool {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+	return eb.sequences[topic] > 0 || len(eb.subscribers.Match(topic)) > 0
+}
+
+// Notify hands data to the subscribers of its topic right away. Unlike
+// Publish it is not stamped, numbered, retained or logged, which is meant for
+// things like typing notices that are only interesting for a moment.
+func (eb *EventBus) Notify(data MessageEvent) {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+	pushed := map[*feed]bool{}
+	for _, sub := range eb.subscribers.Match(data.Topic) {
+		if !pushed[sub.feed] {
+			pushed[sub.feed] = true
+			sub.push(data)
+		}
+	}
+}
+
+// Topics lists every topic something was published on, sorted.
+func (eb *EventBus) Topics() []string {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+	var topics []string
+	for topic := range eb.sequences {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Drain waits until every subscriber has handed its queued events to its
+// feed, or until deadline. It reports whether all queues were drained.
+func (eb *EventBus) Drain(deadline time.Time) bool {
+	for {
+		eb.rm.RLock()
+		drained := true
+		for _, f := range eb.feeds {
+			for sub := range f.subs {
+				if !sub.drained() {
+					drained = false
+				}
+			}
+		}
+		eb.rm.RUnlock()
+		if drained {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Close stops every feed, which ends the streams reading from them once
+// they have taken what is left on the channel, and reports what each queue
+// dropped. Nothing can subscribe after Close.
+func (eb *EventBus) Close() {
+	eb.rm.Lock()
+	defer eb.rm.Unlock()
+	eb.closed = true
+	for _, f := range eb.feeds {
+		for sub := range f.subs {
+			sub.mu.Lock()
+			sub.stop()
+			sub.mu.Unlock()
+		}
+		f.stop()
+	}
+}
+
+// New returns an EventBus that behaves as config says. Call Restore to
+// continue from the log.
+func New(config Config) *EventBus {
+	queue := config.Queue
+	if queue < 1 {
+		queue = 1
+	}
+	retain := config.Retain
+	if retain < 0 {
+		retain = 0
+	}
+	return &EventBus{
+		vector:      config.Vector,
+		retain:      retain,
+		totalOrder:  config.TotalOrder,
+		queue:       queue,
+		overflow:    config.Overflow,
+		log:         config.Log,
+		logger:      config.Logger,
+		subscribers: &pattern.Trie[*subscriber]{},
+		feeds:       map[DataChannel]*feed{},
+		sequences:   map[string]int{},
+		vectors:     map[string]vclock.VClock{},
+		retained:    map[string][]MessageEvent{},
+		speakers:    map[string]string{},
+	}
+}
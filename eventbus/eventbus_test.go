@@ -0,0 +1,581 @@
+package eventbus
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AndersStendevad/disys-m3/vclock"
+)
+
+// receiveAll reads n events from ch. It can be called from any goroutine.
+func receiveAll(t *testing.T, ch DataChannel, n int) []MessageEvent {
+	t.Helper()
+	var events []MessageEvent
+	for len(events) < n {
+		d, ok := <-ch
+		if !ok {
+			t.Errorf("channel closed after %d of %d events", len(events), n)
+			return events
+		}
+		events = append(events, d)
+	}
+	return events
+}
+
+func TestPublishIsFIFOPerTopic(t *testing.T) {
+	const publishers, perPublisher, subscribers = 8, 200, 5
+	topics := []string{"itu", "dtu"}
+
+	bus := New(Config{Queue: publishers * perPublisher, Retain: 1})
+
+	channels := map[string][]DataChannel{}
+	for _, topic := range topics {
+		for i := 0; i < subscribers; i++ {
+			ch := make(DataChannel)
+			bus.Subscribe(topic, ch, Client{Author: fmt.Sprint("reader", i)})
+			channels[topic] = append(channels[topic], ch)
+		}
+	}
+
+	// Publishers race on both topics at once.
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				topic := topics[i%len(topics)]
+				bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: topic, Author: fmt.Sprint("writer", p)})
+			}
+		}(p)
+	}
+
+	received := map[DataChannel][]MessageEvent{}
+	var mu sync.Mutex
+	var readers sync.WaitGroup
+	for _, topic := range topics {
+		for _, ch := range channels[topic] {
+			readers.Add(1)
+			go func(ch DataChannel) {
+				defer readers.Done()
+				events := receiveAll(t, ch, publishers*perPublisher/len(topics))
+				mu.Lock()
+				received[ch] = events
+				mu.Unlock()
+			}(ch)
+		}
+	}
+	wg.Wait()
+	readers.Wait()
+
+	for _, topic := range topics {
+		first := received[channels[topic][0]]
+		for _, ch := range channels[topic] {
+			events := received[ch]
+			for i, d := range events {
+				if d.Topic != topic {
+					t.Fatalf("%s subscriber got an event on %s", topic, d.Topic)
+				}
+				if d.Sequence != i+1 {
+					t.Fatalf("%s event %d has sequence %d", topic, i, d.Sequence)
+				}
+				if i > 0 && d.Lamport <= events[i-1].Lamport {
+					t.Fatalf("%s event %d has timestamp %d after %d", topic, i, d.Lamport, events[i-1].Lamport)
+				}
+				if i >= len(first) || d.Lamport != first[i].Lamport || d.Author != first[i].Author {
+					t.Fatalf("%s subscribers disagree on event %d", topic, i)
+				}
+			}
+		}
+	}
+}
+
+func TestPublishStampsItsOwnTimestamp(t *testing.T) {
+	bus := New(Config{Queue: 100, Retain: 100})
+	ch := make(DataChannel)
+	bus.Subscribe("itu", ch, Client{Author: "Anders"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+		}(i)
+	}
+	wg.Wait()
+
+	// The retained events were stamped under the lock, so the delivered
+	// events must carry exactly the same timestamps in the same order.
+	events := receiveAll(t, ch, 50)
+	for i, d := range events {
+		if want := bus.retained["itu"][i]; d.Lamport != want.Lamport || d.Payload != want.Payload {
+			t.Fatalf("event %d was delivered as %v, stamped as %v", i, d, want)
+		}
+	}
+}
+
+func TestSlowSubscriberKeepsOrder(t *testing.T) {
+	bus := New(Config{Queue: 4, Retain: 1, Overflow: DropOldest})
+	ch := make(DataChannel)
+	bus.Subscribe("itu", ch, Client{Author: "Slow"})
+
+	for i := 0; i < 100; i++ {
+		bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+	}
+
+	// Messages are dropped, but what gets through is still in order and the
+	// last message published always makes it.
+	last := 0
+	for last < 100 {
+		d := <-ch
+		if d.Sequence <= last {
+			t.Fatalf("sequence %d after %d", d.Sequence, last)
+		}
+		last = d.Sequence
+	}
+}
+
+func TestTotalOrderMergesTopics(t *testing.T) {
+	const publishers, perPublisher = 4, 200
+	topics := []string{"itu", "dtu", "ku"}
+
+	bus := New(Config{Queue: publishers * perPublisher, Retain: 1, TotalOrder: true})
+
+	ch := make(DataChannel)
+	other := make(DataChannel)
+	for _, topic := range topics {
+		bus.Subscribe(topic, ch, Client{Author: "Auditor"})
+		bus.Subscribe(topic, other, Client{Author: "Auditor2"})
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: topics[(p+i)%len(topics)], Author: fmt.Sprint("writer", p)})
+			}
+		}(p)
+	}
+
+	var got, otherGot []MessageEvent
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		otherGot = receiveAll(t, other, publishers*perPublisher)
+	}()
+	got = receiveAll(t, ch, publishers*perPublisher)
+	wg.Wait()
+	readers.Wait()
+
+	for i := range got {
+		if i > 0 && got[i].Lamport <= got[i-1].Lamport {
+			t.Fatalf("event %d on %s has timestamp %d after %d", i, got[i].Topic, got[i].Lamport, got[i-1].Lamport)
+		}
+		if got[i].Lamport != otherGot[i].Lamport {
+			t.Fatalf("subscribers disagree on event %d", i)
+		}
+	}
+
+	// Leaving the last topic closes the channel.
+	for _, topic := range topics {
+		bus.Unsubscribe(topic, ch, Client{Author: "Auditor"})
+	}
+	for range ch {
+	}
+}
+
+func TestWildcardSubscriptions(t *testing.T) {
+	bus := New(Config{Queue: 10, Retain: 1})
+
+	all := make(DataChannel)
+	bus.SubscribeAll([]string{"itu/#", "itu/dev/*"}, all, Client{Author: "Anders"})
+	level := make(DataChannel)
+	bus.Subscribe("itu/*", level, Client{Author: "Emil"})
+
+	for _, topic := range []string{"itu", "itu/dev", "itu/dev/backend", "dtu/dev"} {
+		bus.Publish(MessageEvent{Payload: Chat{Text: topic}, Topic: topic, Author: "Sebastian"})
+	}
+
+	// itu/dev/backend matches both patterns of all, but is only delivered once.
+	for _, want := range []string{"itu", "itu/dev", "itu/dev/backend"} {
+		if d := <-all; d.Topic != want {
+			t.Fatalf("itu/# got %s, want %s", d.Topic, want)
+		}
+	}
+	if d := <-level; d.Topic != "itu/dev" {
+		t.Fatalf("itu/* got %s, want itu/dev", d.Topic)
+	}
+	select {
+	case d := <-all:
+		t.Fatalf("itu/# got %s as well", d.Topic)
+	case d := <-level:
+		t.Fatalf("itu/* got %s as well", d.Topic)
+	default:
+	}
+}
+
+func TestSubscribeWhilePublishing(t *testing.T) {
+	const total, subscribers = 500, 20
+	bus := New(Config{Queue: total, Retain: 1})
+	ch := make(DataChannel)
+	bus.Subscribe("itu", ch, Client{Author: "Anders"})
+
+	go func() {
+		for i := 0; i < total; i++ {
+			bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+		}
+	}()
+
+	// Every subscriber gets exactly the events after the sequence number
+	// Subscribe returned, however the publisher is interleaved with it.
+	var wg sync.WaitGroup
+	for i := 0; i < subscribers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ch := make(DataChannel)
+			client := Client{Author: fmt.Sprint("reader", i)}
+			last, err := bus.Subscribe("itu", ch, client)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for last < total {
+				d := <-ch
+				if d.Sequence != last+1 {
+					t.Errorf("reader%d got sequence %d after %d", i, d.Sequence, last)
+					break
+				}
+				last = d.Sequence
+			}
+			bus.Unsubscribe("itu", ch, client)
+			for range ch {
+			}
+		}(i)
+	}
+	receiveAll(t, ch, total)
+	wg.Wait()
+}
+
+func TestUnsubscribeDuringPublish(t *testing.T) {
+	const total = 1000
+	bus := New(Config{Queue: total, Retain: 1})
+	leaving := make(DataChannel)
+	staying := make(DataChannel)
+	client := Client{Author: "Anders"}
+	bus.Subscribe("itu", leaving, client)
+	bus.Subscribe("itu", staying, Client{Author: "Emil"})
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < total; i++ {
+			bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Sebastian"})
+		}
+	}()
+
+	// What reaches the leaving subscriber is the events from the start
+	// without gaps, and its channel is closed once it has left.
+	var got []MessageEvent
+	for d := range leaving {
+		got = append(got, d)
+		if len(got) == 10 {
+			go bus.Unsubscribe("itu", leaving, client)
+		}
+	}
+	for i, d := range got {
+		if d.Sequence != i+1 {
+			t.Fatalf("event %d has sequence %d", i, d.Sequence)
+		}
+	}
+	if len(got) < 10 {
+		t.Fatalf("got %d events", len(got))
+	}
+
+	// The one that stays gets everything.
+	events := receiveAll(t, staying, total)
+	<-published
+	if last := events[len(events)-1].Sequence; last != total {
+		t.Fatalf("last event has sequence %d", last)
+	}
+}
+
+func TestCloseStopsEveryFeed(t *testing.T) {
+	bus := New(Config{Queue: 10, Retain: 1})
+	ch := make(DataChannel)
+	bus.SubscribeAll([]string{"itu", "dtu"}, ch, Client{Author: "Anders"})
+	for _, topic := range []string{"itu", "dtu", "itu"} {
+		bus.Publish(MessageEvent{Payload: Chat{Text: "bye"}, Topic: topic, Author: "Emil"})
+	}
+
+	// Drain waits for the queues to be handed to the channel, so it can
+	// only finish while someone reads.
+	if bus.Drain(time.Now().Add(50 * time.Millisecond)) {
+		t.Fatal("drained without a reader")
+	}
+	got := make(chan []MessageEvent)
+	go func() {
+		var events []MessageEvent
+		for d := range ch {
+			events = append(events, d)
+		}
+		got <- events
+	}()
+	if !bus.Drain(time.Now().Add(time.Second)) {
+		t.Fatal("queues were not drained")
+	}
+	bus.Close()
+	if events := <-got; len(events) != 3 {
+		t.Fatalf("got %d events before the channel closed", len(events))
+	}
+
+	if _, err := bus.Subscribe("itu", make(DataChannel), Client{Author: "Emil"}); err != ErrClosed {
+		t.Fatalf("subscribed after Close: %v", err)
+	}
+}
+
+func TestDisconnectedChannelCantSubscribeAgain(t *testing.T) {
+	bus := New(Config{Queue: 1, Retain: 1, Overflow: Disconnect})
+	ch := make(DataChannel)
+	client := Client{Author: "Slow"}
+	bus.Subscribe("a", ch, client)
+	for i := 0; i < 3; i++ {
+		bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "a", Author: "Emil"})
+	}
+
+	// The feed was stopped for falling behind, so adding a topic must not
+	// start a writer on the channel that is being closed.
+	if _, err := bus.Subscribe("b", ch, client); err != ErrStopped {
+		t.Fatalf("subscribed a stopped channel: %v", err)
+	}
+	bus.Publish(MessageEvent{Payload: Chat{Text: "hi"}, Topic: "b", Author: "Emil"})
+	for range ch {
+	}
+}
+
+func TestNegativeRetainKeepsNothing(t *testing.T) {
+	bus := New(Config{Queue: 1, Retain: -1})
+	bus.Publish(MessageEvent{Payload: Chat{Text: "hi"}, Topic: "itu", Author: "Emil"})
+	if n := len(bus.retained["itu"]); n != 0 {
+		t.Fatalf("retained %d events", n)
+	}
+}
+
+// memoryLog is a Log that keeps the events in memory.
+type memoryLog struct {
+	mu     sync.Mutex
+	events map[string][]MessageEvent
+}
+
+func (l *memoryLog) Append(d MessageEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.events == nil {
+		l.events = map[string][]MessageEvent{}
+	}
+	l.events[d.Topic] = append(l.events[d.Topic], d)
+	return nil
+}
+
+func (l *memoryLog) Read(topic string) ([]MessageEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]MessageEvent(nil), l.events[topic]...), nil
+}
+
+func (l *memoryLog) Topics() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var topics []string
+	for topic := range l.events {
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// A client gets the history up to the sequence number Subscribe returned and
+// the live events after it, so nothing published around the switch is lost
+// or sent twice, whether the history comes from memory or from the log. All
+// live events come in while the history is sent, which takes far more than
+// the queue holds, whether that is the server's default of 64 or just one.
+func TestHistoryThenLive(t *testing.T) {
+	const before, during = 50, 200
+	for _, queue := range []int{64, 1} {
+		for _, c := range []struct {
+			name   string
+			retain int
+			log    bool
+			replay Replay
+			first  int
+		}{
+			{"replay retained", before + during, false, Replay{Last: before + during}, 1},
+			{"resume retained", before + during, false, Replay{After: 20}, 21},
+			{"replay from log", 1, true, Replay{Last: before + during}, 1},
+			{"resume from log", 1, true, Replay{After: 20}, 21},
+		} {
+			t.Run(fmt.Sprint(c.name, " queue ", queue), func(t *testing.T) {
+				config := Config{Queue: queue, Retain: c.retain}
+				if c.log {
+					config.Log = &memoryLog{}
+				}
+				bus := New(config)
+				for i := 0; i < before; i++ {
+					bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+				}
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					for i := before; i < before+during; i++ {
+						bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+					}
+				}()
+
+				ch := make(DataChannel)
+				upto, err := bus.Subscribe("itu", ch, Client{Author: "Anders", Replaying: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				history, err := bus.History("itu", upto, c.replay)
+				if err != nil {
+					t.Fatal(err)
+				}
+				<-done
+				bus.Live(ch)
+				events := append(history, receiveAll(t, ch, before+during-upto)...)
+				bus.Unsubscribe("itu", ch, Client{Author: "Anders"})
+				for range ch {
+				}
+
+				if len(events) != before+during-c.first+1 {
+					t.Fatalf("got %d events from %d, with %d from history", len(events), c.first, len(history))
+				}
+				for i, d := range events {
+					if d.Sequence != c.first+i || d.Text() != fmt.Sprint("Emil: ", c.first+i-1) {
+						t.Fatalf("event %d is #%d %q", i, d.Sequence, d.Text())
+					}
+				}
+			})
+		}
+	}
+}
+
+// Once live, the overflow policy applies again, but only to the events that
+// come in after the ones that queued up during the replay.
+func TestLiveKeepsTheBacklog(t *testing.T) {
+	bus := New(Config{Queue: 2})
+	ch := make(DataChannel)
+	bus.Subscribe("itu", ch, Client{Author: "Anders", Replaying: true})
+	for i := 1; i <= 10; i++ {
+		bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+	}
+	bus.Live(ch)
+	// Of the events after the backlog the queue holds two, the newest.
+	for i := 11; i <= 21; i++ {
+		bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+	}
+	var got []int
+	for _, d := range receiveAll(t, ch, 12) {
+		got = append(got, d.Sequence)
+	}
+	if fmt.Sprint(got) != "[1 2 3 4 5 6 7 8 9 10 20 21]" {
+		t.Fatalf("got %v", got)
+	}
+	bus.Unsubscribe("itu", ch, Client{Author: "Anders"})
+	for range ch {
+	}
+}
+
+// DropNewest keeps what is in the queue and drops what comes in after it is
+// full. The count is there to read and is logged when the bus is closed.
+func TestDropNewest(t *testing.T) {
+	var out bytes.Buffer
+	bus := New(Config{Queue: 2, Overflow: DropNewest, Logger: log.New(&out, "", 0)})
+	ch := make(DataChannel)
+	// The first event is in the backlog, so it is sent whether or not the
+	// writer took it from the queue yet, and the queue holds two after it.
+	bus.Subscribe("itu", ch, Client{Author: "Anders", Replaying: true})
+	bus.Publish(MessageEvent{Payload: Chat{Text: "1"}, Topic: "itu", Author: "Emil"})
+	bus.Live(ch)
+	for i := 2; i <= 10; i++ {
+		bus.Publish(MessageEvent{Payload: Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Emil"})
+	}
+	if n := bus.Dropped(ch); n != 7 {
+		t.Errorf("dropped %d events, want 7", n)
+	}
+	var got []int
+	for _, d := range receiveAll(t, ch, 3) {
+		got = append(got, d.Sequence)
+	}
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Fatalf("got %v", got)
+	}
+	bus.Close()
+	for range ch {
+	}
+	if !strings.Contains(out.String(), "dropped 7 messages for Anders") {
+		t.Errorf("the dropped events are not in the log:\n%s", out.String())
+	}
+	if n := bus.Dropped(make(DataChannel)); n != 0 {
+		t.Errorf("a channel that never subscribed dropped %d events", n)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	bus := New(Config{Queue: 1, Logger: log.New(&out, "", 0)})
+	ch := make(DataChannel)
+	bus.Subscribe("itu", ch, Client{Author: "Anders"})
+	bus.Publish(MessageEvent{Payload: Chat{Text: "hi"}, Topic: "itu", Author: "Emil"})
+	bus.Unsubscribe("itu", ch, Client{Author: "Anders"})
+	for range ch {
+	}
+	for _, line := range []string{"subscribed Anders to [itu]", "received on itu: Emil: hi", "unsubscribed Anders from itu"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("%q is not in the log:\n%s", line, out.String())
+		}
+	}
+
+	// Without a logger the bus is quiet, and doesn't fail.
+	New(Config{}).Publish(MessageEvent{Payload: Chat{Text: "hi"}, Topic: "itu", Author: "Emil"})
+}
+
+// In vector mode a message that was delivered to an author happened before
+// what they publish next, and messages from authors that saw nothing of each
+// other are concurrent. The clocks go on from the log after a restart.
+func TestVectorClocks(t *testing.T) {
+	events := &memoryLog{}
+	bus := New(Config{Vector: true, Queue: 10, Retain: 10, Log: events})
+	a, _ := bus.Publish(MessageEvent{Payload: Chat{Text: "hi"}, Topic: "itu", Author: "Anders"})
+	bus.Delivered("Emil", a)
+	b, _ := bus.Publish(MessageEvent{Payload: Chat{Text: "hi Anders"}, Topic: "itu", Author: "Emil"})
+	c, _ := bus.Publish(MessageEvent{Payload: Chat{Text: "anyone?"}, Topic: "itu", Author: "Sebastian"})
+	if o := b.Vector.Compare(a.Vector); o != vclock.After {
+		t.Errorf("reply %v is %v the message %v", b.Vector, o, a.Vector)
+	}
+	if o := c.Vector.Compare(b.Vector); o != vclock.Concurrent {
+		t.Errorf("%v is %v %v", c.Vector, o, b.Vector)
+	}
+	// Each author ticks their own entry.
+	if b.Vector["Emil"] != 1 || c.Vector["Sebastian"] != 1 {
+		t.Errorf("got %v and %v", b.Vector, c.Vector)
+	}
+
+	restarted := New(Config{Vector: true, Queue: 10, Retain: 10, Log: events})
+	if err := restarted.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := restarted.Publish(MessageEvent{Payload: Chat{Text: "back again"}, Topic: "itu", Author: "Emil"})
+	if o := d.Vector.Compare(b.Vector); o != vclock.After || d.Vector["Emil"] != 2 {
+		t.Errorf("after a restart %v is %v %v", d.Vector, o, b.Vector)
+	}
+}
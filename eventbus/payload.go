@@ -0,0 +1,50 @@
+package eventbus
+
+// Payload is what an event carries: one of Chat, Join, Leave, Notice,
+// TopicChange and Typing.
+type Payload interface {
+	// text is the event as it is shown in the chat.
+	text(author string) string
+}
+
+// Chat is something the author said.
+type Chat struct {
+	Text string
+}
+
+// Join is the author joining the topic.
+type Join struct{}
+
+// Leave is the author leaving the topic.
+type Leave struct{}
+
+// Notice is a notice from the server itself, like that it is shutting down.
+type Notice struct {
+	Text string
+}
+
+// TopicChange is the author changing who owns the topic or whether it is private.
+type TopicChange struct {
+	Owner   string
+	Private bool
+}
+
+// Typing is the author typing on the topic.
+type Typing struct{}
+
+func (p Chat) text(author string) string   { return author + ": " + p.Text }
+func (Join) text(author string) string     { return author + " joined" }
+func (Leave) text(author string) string    { return author + " left" }
+func (p Notice) text(author string) string { return p.Text }
+func (Typing) text(author string) string   { return author + " is typing" }
+
+func (p TopicChange) text(author string) string {
+	visibility := "open"
+	if p.Private {
+		visibility = "private"
+	}
+	if p.Owner != "" && p.Owner != author {
+		return author + " handed the " + visibility + " topic to " + p.Owner
+	}
+	return author + " made the topic " + visibility
+}
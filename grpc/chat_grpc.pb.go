@@ -24,6 +24,20 @@ const _ = grpc.SupportPackageIsVersion7
 type ChatClient interface {
 	Send(ctx context.Context, in *Message, opts ...grpc.CallOption) (*MessageAck, error)
 	Receive(ctx context.Context, in *Request, opts ...grpc.CallOption) (Chat_ReceiveClient, error)
+	Topics(ctx context.Context, in *TopicChange, opts ...grpc.CallOption) (*MessageAck, error)
+	// Sends and receives on one stream. The first frame must be a hello.
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Chat_ConnectClient, error)
+	// Tells the server the author has read a message delivered to them.
+	Read(ctx context.Context, in *Receipt, opts ...grpc.CallOption) (*MessageAck, error)
+	// Lists who a message was delivered to and who read it.
+	Receipts(ctx context.Context, in *ReceiptQuery, opts ...grpc.CallOption) (*ReceiptList, error)
+	// Trades a password for a token, which is sent as "authorization:
+	// Bearer TOKEN" metadata on every other call.
+	Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error)
+	// Changes who may read and write a topic, and returns who may now. The
+	// first author to change a topic owns it, after that only the owner
+	// can. A change with nothing in it just returns the list.
+	Access(ctx context.Context, in *AccessChange, opts ...grpc.CallOption) (*AccessList, error)
 }
 
 type chatClient struct {
@@ -75,12 +89,102 @@ func (x *chatReceiveClient) Recv() (*Message, error) {
 	return m, nil
 }
 
+func (c *chatClient) Topics(ctx context.Context, in *TopicChange, opts ...grpc.CallOption) (*MessageAck, error) {
+	out := new(MessageAck)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Topics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Chat_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chat_ServiceDesc.Streams[1], "/chat.Chat/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatConnectClient{stream}
+	return x, nil
+}
+
+type Chat_ConnectClient interface {
+	Send(*ClientFrame) error
+	Recv() (*ServerFrame, error)
+	grpc.ClientStream
+}
+
+type chatConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatConnectClient) Send(m *ClientFrame) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatConnectClient) Recv() (*ServerFrame, error) {
+	m := new(ServerFrame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) Read(ctx context.Context, in *Receipt, opts ...grpc.CallOption) (*MessageAck, error) {
+	out := new(MessageAck)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Read", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Receipts(ctx context.Context, in *ReceiptQuery, opts ...grpc.CallOption) (*ReceiptList, error) {
+	out := new(ReceiptList)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Receipts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Login", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Access(ctx context.Context, in *AccessChange, opts ...grpc.CallOption) (*AccessList, error) {
+	out := new(AccessList)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Access", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChatServer is the server API for Chat service.
 // All implementations must embed UnimplementedChatServer
 // for forward compatibility
 type ChatServer interface {
 	Send(context.Context, *Message) (*MessageAck, error)
 	Receive(*Request, Chat_ReceiveServer) error
+	Topics(context.Context, *TopicChange) (*MessageAck, error)
+	// Sends and receives on one stream. The first frame must be a hello.
+	Connect(Chat_ConnectServer) error
+	// Tells the server the author has read a message delivered to them.
+	Read(context.Context, *Receipt) (*MessageAck, error)
+	// Lists who a message was delivered to and who read it.
+	Receipts(context.Context, *ReceiptQuery) (*ReceiptList, error)
+	// Trades a password for a token, which is sent as "authorization:
+	// Bearer TOKEN" metadata on every other call.
+	Login(context.Context, *Credentials) (*Token, error)
+	// Changes who may read and write a topic, and returns who may now. The
+	// first author to change a topic owns it, after that only the owner
+	// can. A change with nothing in it just returns the list.
+	Access(context.Context, *AccessChange) (*AccessList, error)
 	mustEmbedUnimplementedChatServer()
 }
 
@@ -94,6 +198,24 @@ func (UnimplementedChatServer) Send(context.Context, *Message) (*MessageAck, err
 func (UnimplementedChatServer) Receive(*Request, Chat_ReceiveServer) error {
 	return status.Errorf(codes.Unimplemented, "method Receive not implemented")
 }
+func (UnimplementedChatServer) Topics(context.Context, *TopicChange) (*MessageAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Topics not implemented")
+}
+func (UnimplementedChatServer) Connect(Chat_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedChatServer) Read(context.Context, *Receipt) (*MessageAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedChatServer) Receipts(context.Context, *ReceiptQuery) (*ReceiptList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Receipts not implemented")
+}
+func (UnimplementedChatServer) Login(context.Context, *Credentials) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedChatServer) Access(context.Context, *AccessChange) (*AccessList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Access not implemented")
+}
 func (UnimplementedChatServer) mustEmbedUnimplementedChatServer() {}
 
 // UnsafeChatServer may be embedded to opt out of forward compatibility for this service.
@@ -146,6 +268,122 @@ func (x *chatReceiveServer) Send(m *Message) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Chat_Topics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopicChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Topics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Topics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Topics(ctx, req.(*TopicChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServer).Connect(&chatConnectServer{stream})
+}
+
+type Chat_ConnectServer interface {
+	Send(*ServerFrame) error
+	Recv() (*ClientFrame, error)
+	grpc.ServerStream
+}
+
+type chatConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatConnectServer) Send(m *ServerFrame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatConnectServer) Recv() (*ClientFrame, error) {
+	m := new(ClientFrame)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chat_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Receipt)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Read",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Read(ctx, req.(*Receipt))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Receipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiptQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Receipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Receipts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Receipts(ctx, req.(*ReceiptQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Login(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Access_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccessChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Access(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Access",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Access(ctx, req.(*AccessChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Chat_ServiceDesc is the grpc.ServiceDesc for Chat service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,6 +395,26 @@ var Chat_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Send",
 			Handler:    _Chat_Send_Handler,
 		},
+		{
+			MethodName: "Topics",
+			Handler:    _Chat_Topics_Handler,
+		},
+		{
+			MethodName: "Read",
+			Handler:    _Chat_Read_Handler,
+		},
+		{
+			MethodName: "Receipts",
+			Handler:    _Chat_Receipts_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Chat_Login_Handler,
+		},
+		{
+			MethodName: "Access",
+			Handler:    _Chat_Access_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -164,6 +422,12 @@ var Chat_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Chat_Receive_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Connect",
+			Handler:       _Chat_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "grpc/chat.proto",
 }
@@ -0,0 +1,126 @@
+// Package msglog stores the chat history on disk. Every topic has its own
+// append-only file in the log directory with one message per line, so the
+// server can replay a topic to subscribers that join late.
+package msglog
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	chat "github.com/AndersStendevad/disys-m3/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const suffix = ".log"
+
+// Log is the set of topic files in one directory. It is safe for concurrent use.
+type Log struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// Open opens the log in dir, creating the directory if it does not exist.
+func Open(dir string) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Log{dir: dir, files: map[string]*os.File{}}, nil
+}
+
+// path is the file of a topic. Topics are escaped so they can't leave the directory.
+func (l *Log) path(topic string) string {
+	return filepath.Join(l.dir, url.PathEscape(topic)+suffix)
+}
+
+// Append adds m to the end of the file of m.Topic.
+func (l *Log) Append(m *chat.Message) error {
+	line, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, found := l.files[m.Topic]
+	if !found {
+		f, err = os.OpenFile(l.path(m.Topic), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		l.files[m.Topic] = f
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Read returns every message logged on topic, oldest first.
+func (l *Log) Read(topic string) ([]*chat.Message, error) {
+	f, err := os.Open(l.path(topic))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []*chat.Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		m := &chat.Message{}
+		if err := protojson.Unmarshal(scanner.Bytes(), m); err != nil {
+			return messages, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, scanner.Err()
+}
+
+// Topics lists the topics that have a file in the log.
+func (l *Log) Topics() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(l.dir, "*"+suffix))
+	if err != nil {
+		return nil, err
+	}
+	var topics []string
+	for _, name := range names {
+		topic, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(name), suffix))
+		if err != nil {
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// Sync flushes every open topic file to disk.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var first error
+	for _, f := range l.files {
+		if err := f.Sync(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes every open topic file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var first error
+	for topic, f := range l.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(l.files, topic)
+	}
+	return first
+}
@@ -0,0 +1,149 @@
+// Package pattern matches hierarchical topics such as "itu/dev/backend"
+// against subscription patterns. The levels of a topic are separated by "/".
+// In a pattern "*" matches exactly one level and "#", which can only be the
+// last level, matches any number of levels: "itu/#" matches "itu",
+// "itu/dev" and "itu/dev/backend", while "itu/*" only matches "itu/dev".
+package pattern
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	separator = "/"
+	one       = "*"
+	rest      = "#"
+)
+
+// ValidTopic checks that topic can be published to, which means it is not
+// empty and has no wildcards.
+func ValidTopic(topic string) error {
+	if topic == "" {
+		return errors.New("topic is empty")
+	}
+	if strings.ContainsAny(topic, one+rest) {
+		return errors.New("topic " + topic + " contains a wildcard")
+	}
+	return nil
+}
+
+// Valid checks that pattern can be subscribed to.
+func Valid(pattern string) error {
+	if pattern == "" {
+		return errors.New("pattern is empty")
+	}
+	levels := strings.Split(pattern, separator)
+	for i, level := range levels {
+		if level == rest && i != len(levels)-1 {
+			return errors.New("pattern " + pattern + " has # before its last level")
+		}
+		if level != one && level != rest && strings.ContainsAny(level, one+rest) {
+			return errors.New("pattern " + pattern + " has a wildcard inside a level")
+		}
+	}
+	return nil
+}
+
+// HasWildcard tells whether pattern can match other topics than itself.
+func HasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, one+rest)
+}
+
+// Match tells whether topic matches pattern.
+func Match(pattern string, topic string) bool {
+	var t Trie[bool]
+	t.Add(pattern, true)
+	return len(t.Match(topic)) > 0
+}
+
+// Trie holds values under patterns and finds the values of every pattern
+// matching a topic, walking one level at a time. The zero value is empty and
+// ready to use. It is not safe for concurrent use.
+type Trie[T comparable] struct {
+	root node[T]
+}
+
+type node[T comparable] struct {
+	children map[string]*node[T]
+	values   []T
+}
+
+// Add stores value under pattern.
+func (t *Trie[T]) Add(pattern string, value T) {
+	n := &t.root
+	for _, level := range strings.Split(pattern, separator) {
+		if n.children == nil {
+			n.children = map[string]*node[T]{}
+		}
+		child, found := n.children[level]
+		if !found {
+			child = &node[T]{}
+			n.children[level] = child
+		}
+		n = child
+	}
+	n.values = append(n.values, value)
+}
+
+// Remove takes value away from pattern and reports whether it was there.
+// Levels that are left empty are removed too.
+func (t *Trie[T]) Remove(pattern string, value T) bool {
+	return t.root.remove(strings.Split(pattern, separator), value)
+}
+
+func (n *node[T]) remove(levels []string, value T) bool {
+	if len(levels) == 0 {
+		for i, v := range n.values {
+			if v == value {
+				n.values = append(n.values[:i], n.values[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	child, found := n.children[levels[0]]
+	if !found || !child.remove(levels[1:], value) {
+		return false
+	}
+	if len(child.values) == 0 && len(child.children) == 0 {
+		delete(n.children, levels[0])
+	}
+	return true
+}
+
+// Values returns the values stored under exactly pattern.
+func (t *Trie[T]) Values(pattern string) []T {
+	n := &t.root
+	for _, level := range strings.Split(pattern, separator) {
+		child, found := n.children[level]
+		if !found {
+			return nil
+		}
+		n = child
+	}
+	return n.values
+}
+
+// Match returns the values of every pattern that matches topic.
+func (t *Trie[T]) Match(topic string) []T {
+	var values []T
+	t.root.match(strings.Split(topic, separator), &values)
+	return values
+}
+
+func (n *node[T]) match(levels []string, values *[]T) {
+	if child, found := n.children[rest]; found {
+		*values = append(*values, child.values...)
+	}
+	if len(levels) == 0 {
+		*values = append(*values, n.values...)
+		return
+	}
+	if child, found := n.children[levels[0]]; found {
+		child.match(levels[1:], values)
+	}
+	if child, found := n.children[one]; found {
+		child.match(levels[1:], values)
+	}
+}
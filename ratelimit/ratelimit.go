@@ -0,0 +1,92 @@
+// Package ratelimit limits how often something may happen for each key, like
+// how often an author may send, with a token bucket per key. A bucket holds
+// up to burst tokens and gets rate new ones every second. Every event takes
+// a token, and when the bucket is empty the event has to wait.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many calls go by between forgetting the full buckets,
+// which are the same as buckets that were never used.
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// Limiter is a token bucket for every key. It is safe for concurrent use. A
+// nil Limiter allows everything.
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	calls   int
+}
+
+// New returns a Limiter that allows rate events per second for each key,
+// and up to burst at once.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), buckets: map[string]*bucket{}}
+}
+
+// fill returns the bucket of key with the tokens added since it was last used.
+func (l *Limiter) fill(key string, now time.Time) *bucket {
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.at).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.at = now
+	return b
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key := range l.buckets {
+		if l.fill(key, now).tokens == l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Allow takes a token from the bucket of key. If the bucket is empty it
+// returns false and how long it takes until there is a token again.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.calls++; l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+	b := l.fill(key, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Refund puts back a token that Allow took, for an event that didn't
+// happen after all.
+func (l *Limiter) Refund(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.fill(key, time.Now())
+	if b.tokens++; b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+}
@@ -57,120 +57,1199 @@ package main
 import (
     "sync"
     "fmt"
+    "log"
     "net"
+    "os"
+    "os/signal"
+    "syscall"
+    "flag"
     chat "github.com/AndersStendevad/disys-m3/grpc"
+    "github.com/AndersStendevad/disys-m3/acl"
+    "github.com/AndersStendevad/disys-m3/auth"
+    "github.com/AndersStendevad/disys-m3/eventbus"
+    "github.com/AndersStendevad/disys-m3/msglog"
+    "github.com/AndersStendevad/disys-m3/pattern"
+    "github.com/AndersStendevad/disys-m3/ratelimit"
     "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/protobuf/types/known/durationpb"
+    "google.golang.org/grpc/status"
+    "bufio"
     "context"
+    "crypto/rand"
+    "math"
+    "sort"
     "strconv"
+    "strings"
+    "time"
 )
 
-type MessageEvent struct {
-   Data interface{}
-   Topic string
-   lamport_timestamp int
+// Old clients only read the message text, so they need the timestamp baked into it.
+var legacy = flag.Bool("legacy", false, "prefix message text with \"Lamport timestamp: N | \" for old clients")
+var vector = flag.Bool("vector", false, "stamp events with a vector clock that has an entry per author")
+var retain = flag.Int("retain", 100, "number of events kept in memory per topic for clients that reconnect")
+var totalOrder = flag.Bool("total-order", false, "deliver all topics a client follows merged in one global order")
+var queue = flag.Int("queue", 64, "number of events that can wait for each subscriber")
+var overflow = flag.String("overflow", "drop-oldest", "what to do when a subscriber's queue is full: drop-oldest, drop-newest or disconnect")
+var logDir = flag.String("log", "", "directory to keep a log of every topic in, so history can be replayed")
+var dedup = flag.Duration("dedup", 10*time.Minute, "how long the ack of a Send with an idempotency key is kept for retries")
+var maxSize = flag.Int("max-size", 4096, "largest message text in bytes that Send accepts")
+var users = flag.String("users", "", "file of NAME:HASH lines with a bcrypt hash, as htpasswd -nbB prints them; when set authors must log in and can only use their own name")
+var secret = flag.String("secret", "", "key tokens are signed with; by default a random one, so tokens stop working when the server restarts")
+var tokenTTL = flag.Duration("token-ttl", 24*time.Hour, "how long a login token is valid")
+var certFile = flag.String("cert", "", "certificate file to serve TLS with")
+var keyFile = flag.String("key", "", "key file of -cert")
+var clientCA = flag.String("client-ca", "", "CA file clients must have a certificate from; the common name of the certificate is then the author")
+var admins = flag.String("admins", "", "authors, separated by commas, who may claim topics that others already use")
+var aclFile = flag.String("acl", "", "file to keep who may read and write each topic in; by default it is forgotten when the server stops")
+var authorRate = flag.Float64("author-rate", 5, "messages per second each author may send; 0 for no limit")
+var authorBurst = flag.Int("author-burst", 10, "messages an author may send at once before -author-rate applies")
+var topicRate = flag.Float64("topic-rate", 50, "messages per second each topic takes; 0 for no limit")
+var topicBurst = flag.Int("topic-burst", 100, "messages a topic takes at once before -topic-rate applies")
+var loginRate = flag.Float64("login-rate", 0.2, "logins per second each author may try; 0 for no limit")
+var loginBurst = flag.Int("login-burst", 5, "logins an author may try at once before -login-rate applies")
+var shutdownTimeout = flag.Duration("shutdown-timeout", 10*time.Second, "how long a stopping server waits for subscribers to get what is left in their queues")
+var keepReceipts = flag.Int("receipts", 10000, "number of messages the delivery and read receipts are kept for")
+
+type ChatServer struct {
+    chat.UnimplementedChatServer
+    bus *eventbus.EventBus
+    // The log the bus appends to, if there is one, which is flushed on shutdown.
+    log *msglog.Log
+    mu sync.Mutex
+    // The Receive streams that can change topics, by session.
+    sessions map[string]*session
+    // The recent Sends with an idempotency key, or nil to not look for retries.
+    recent *dedupWindow
+    // closing is set when the server shuts down, after which no new streams
+    // or topics are subscribed.
+    closing bool
+    receipts *receipts
+    acls *acl.Store
+    // admins may claim a topic others already use.
+    admins map[string]bool
+    // How fast each author may send and each topic takes messages, nil for no limit.
+    authorLimit *ratelimit.Limiter
+    topicLimit *ratelimit.Limiter
+    // loginLimit slows down guessing the password of an author.
+    loginLimit *ratelimit.Limiter
+    // Who can log in and what signs their tokens, when logins are required.
+    users auth.Users
+    signer *auth.Signer
+}
+
+// identify returns the author of a call. When logins are required that is
+// who the token of the call was issued to, whatever name the client claims.
+func identify(ctx context.Context, claimed string) string {
+    if author, ok := auth.FromContext(ctx); ok {
+        return author
+    }
+    return claimed
+}
+
+func newChatServer(bus *eventbus.EventBus) *ChatServer {
+    acls, _ := acl.Open("")
+    s := &ChatServer{bus: bus, sessions: map[string]*session{}, receipts: newReceipts(*keepReceipts), acls: acls, admins: map[string]bool{}}
+    for _, admin := range strings.Split(*admins, ",") {
+        if admin = strings.TrimSpace(admin); admin != "" {
+            s.admins[admin] = true
+        }
+    }
+    if *authorRate > 0 {
+        s.authorLimit = ratelimit.New(*authorRate, *authorBurst)
+    }
+    if *topicRate > 0 {
+        s.topicLimit = ratelimit.New(*topicRate, *topicBurst)
+    }
+    if *loginRate > 0 {
+        s.loginLimit = ratelimit.New(*loginRate, *loginBurst)
+    }
+    return s
+}
+
+// readable checks that author may receive every topic. Patterns are always
+// allowed, the topics they match that author can't read are just not delivered.
+func (s *ChatServer) readable(topics []string, author string) error {
+    for _, topic := range topics {
+        if !pattern.HasWildcard(topic) && !s.acls.CanRead(topic, author) {
+            return status.Errorf(codes.PermissionDenied, "%s may not read %s", author, topic)
+        }
+    }
+    return nil
 }
 
-type DataChannel chan MessageEvent
+// change changes the topics of a session, if its author may read the new ones.
+func (s *ChatServer) change(sess *session, in *chat.TopicChange) error {
+    if len(in.Add) > 0 {
+        if err := s.accepting(); err != nil {
+            return err
+        }
+    }
+    if err := s.readable(in.Add, sess.msg.Author); err != nil {
+        return err
+    }
+    return sess.change(in)
+}
 
-type DataChannelSlice [] DataChannel
+// receipts keeps who each chat message was delivered to and who read it, by
+// message id. Only the last limit messages are kept.
+type receipts struct {
+    mu sync.Mutex
+    limit int
+    ids []string
+    deliveredTo map[string]map[string]time.Time
+    readBy map[string]map[string]time.Time
+}
 
-type EventBus struct {
-   subscribers map[string]DataChannelSlice
-   rm sync.RWMutex
-   lamport_timestamp int
+func newReceipts(limit int) *receipts {
+    return &receipts{limit: limit, deliveredTo: map[string]map[string]time.Time{}, readBy: map[string]map[string]time.Time{}}
 }
 
-func (eb *EventBus)Subscribe(topic string, ch DataChannel, msg *chat.Request) {
-    eb.rm.Lock()
-    eb.lamport_timestamp++
-    fmt.Println("time:", eb.lamport_timestamp," Server received subscriber:", msg)
+// deliver records that d reached author. Authors don't get receipts for
+// their own messages, and joins and leaves get none at all.
+func (r *receipts) deliver(d eventbus.MessageEvent, author string) {
+    if _, ok := d.Payload.(eventbus.Chat); !ok || d.Author == author {
+        return
+    }
+    id := d.ID()
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.deliveredTo[id] == nil {
+        r.deliveredTo[id] = map[string]time.Time{}
+        r.readBy[id] = map[string]time.Time{}
+        r.ids = append(r.ids, id)
+        if len(r.ids) > r.limit {
+            delete(r.deliveredTo, r.ids[0])
+            delete(r.readBy, r.ids[0])
+            r.ids = r.ids[1:]
+        }
+    }
+    if _, found := r.deliveredTo[id][author]; !found {
+        r.deliveredTo[id][author] = time.Now()
+    }
+}
 
-    if prev, found := eb.subscribers[topic]; found {
-        eb.subscribers[topic] = append(prev, ch)
-    } else {
-        eb.subscribers[topic] = append([]DataChannel{}, ch)
+// read records that author read the message with id, which must have been
+// delivered to them.
+func (r *receipts) read(id string, author string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, found := r.deliveredTo[id][author]; !found {
+        return status.Errorf(codes.NotFound, "message %s was not delivered to %s", id, author)
+    }
+    if _, found := r.readBy[id][author]; !found {
+        r.readBy[id][author] = time.Now()
     }
-    eb.rm.Unlock()
+    return nil
 }
 
-func (eb *EventBus)Unsubscribe(topic string, ch DataChannel, msg *chat.Request) {
-    eb.rm.Lock()
-    eb.lamport_timestamp++
-    fmt.Println("time:", eb.lamport_timestamp," Server lost subscriber:", msg)
-    if prev, found := eb.subscribers[topic]; found {
-        for i, c := range prev {
-            if c == ch {
-                eb.subscribers[topic] = append(prev[:i], prev[i+1:]...)
-                break
-            }
+// of lists the receipts of the message with id, oldest first.
+func (r *receipts) of(id string) *chat.ReceiptList {
+    list := func(by map[string]time.Time) []*chat.Receipt {
+        var receipts []*chat.Receipt
+        for author, at := range by {
+            receipts = append(receipts, &chat.Receipt{Id: id, Author: author, Time: at.UnixMilli()})
         }
+        sort.Slice(receipts, func(i, j int) bool { return receipts[i].Time < receipts[j].Time })
+        return receipts
     }
-    eb.rm.Unlock()
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return &chat.ReceiptList{Id: id, Delivered: list(r.deliveredTo[id]), Read: list(r.readBy[id])}
 }
 
-func (eb *EventBus) Publish(topic string, data interface{}) {
-    eb.rm.Lock()
-    eb.lamport_timestamp++
-    fmt.Println("time:", eb.lamport_timestamp," Server received message on topic:", topic, ", with message:", data)
-    eb.lamport_timestamp++
-    fmt.Println("time:", eb.lamport_timestamp," Server broadcast message to subscribers")
-    if chans, found := eb.subscribers[topic]; found {
-        channels := append(DataChannelSlice{}, chans...)
-        go func(data MessageEvent, dataChannelSlices DataChannelSlice) {
-            for _, ch := range dataChannelSlices {
-                data.lamport_timestamp = eb.lamport_timestamp
-                ch <- data
-            }
-        }(MessageEvent{Data: data, Topic: topic}, channels)
+// sendKey identifies a Send by its author and idempotency key.
+type sendKey struct {
+    author string
+    key string
+}
+
+// sent is the outcome of a Send. done is closed once ack and err are set.
+type sent struct {
+    done chan struct{}
+    at time.Time
+    ack *chat.MessageAck
+    err error
+}
+
+// dedupWindow remembers the Sends made within window by their idempotency
+// key, so a client that retries a Send gets the original ack back instead of
+// publishing the message again.
+type dedupWindow struct {
+    mu sync.Mutex
+    window time.Duration
+    sends map[sendKey]*sent
+    // The keys in the order they were sent in, to forget them again.
+    order []sendKey
+}
+
+func newDedupWindow(window time.Duration) *dedupWindow {
+    return &dedupWindow{window: window, sends: map[sendKey]*sent{}}
+}
+
+// do calls send, unless a Send with the same key was made within the window.
+// Then it returns what that Send returned, waiting for it if it is still
+// going. A Send that failed is forgotten so it can be tried again.
+func (w *dedupWindow) do(key sendKey, send func() (*chat.MessageAck, error)) (*chat.MessageAck, error) {
+    now := time.Now()
+    w.mu.Lock()
+    for len(w.order) > 0 {
+        s, found := w.sends[w.order[0]]
+        if found && now.Sub(s.at) < w.window {
+            break
+        }
+        if found {
+            delete(w.sends, w.order[0])
+        }
+        w.order = w.order[1:]
+    }
+    if s, found := w.sends[key]; found {
+        w.mu.Unlock()
+        <-s.done
+        return s.ack, s.err
+    }
+    s := &sent{done: make(chan struct{}), at: now}
+    w.sends[key] = s
+    w.order = append(w.order, key)
+    w.mu.Unlock()
+
+    s.ack, s.err = send()
+    if s.err != nil {
+        w.mu.Lock()
+        delete(w.sends, key)
+        w.mu.Unlock()
     }
-    eb.rm.Unlock()
+    close(s.done)
+    return s.ack, s.err
 }
 
-var eb = &EventBus{
-   subscribers: map[string]DataChannelSlice{},
+// message converts an event to what is streamed to the clients and kept in
+// the log. A chat message keeps just the text in the message field, as it
+// always did, and every other event the text it is shown with.
+func message(d eventbus.MessageEvent) *chat.Message {
+    m := &chat.Message{
+        Author: d.Author,
+        Topic: d.Topic,
+        Lamport: uint64(d.Lamport),
+        Vector: d.Vector,
+        Sequence: uint64(d.Sequence),
+        Id: d.ID(),
+        Message: d.Text(),
+    }
+    switch p := d.Payload.(type) {
+    case eventbus.Join:
+        m.Kind = chat.EventKind_JOIN
+        m.Payload = &chat.Message_Joined{Joined: &chat.Joined{}}
+    case eventbus.Leave:
+        m.Kind = chat.EventKind_LEAVE
+        m.Payload = &chat.Message_Left{Left: &chat.Left{}}
+    case eventbus.Notice:
+        m.Kind = chat.EventKind_SYSTEM
+        m.Payload = &chat.Message_Notice{Notice: &chat.SystemNotice{Text: p.Text}}
+    case eventbus.TopicChange:
+        m.Kind = chat.EventKind_TOPIC
+        m.Payload = &chat.Message_TopicChanged{TopicChanged: &chat.TopicChanged{Owner: p.Owner, Private: p.Private}}
+    case eventbus.Typing:
+        m.Kind = chat.EventKind_TYPING
+        m.Payload = &chat.Message_Typing{Typing: &chat.Typing{Author: d.Author, Topic: d.Topic}}
+    case eventbus.Chat:
+        m.Message = p.Text
+        m.Payload = &chat.Message_Chat{Chat: &chat.ChatMessage{Text: p.Text}}
+    default:
+        // An event without a payload can still be streamed.
+        m.Message = ""
+        m.Payload = &chat.Message_Chat{Chat: &chat.ChatMessage{}}
+    }
+    return m
 }
 
-type ChatServer struct {
-    chat.UnimplementedChatServer
+// eventOf turns a message read back from the log into an event again.
+func eventOf(m *chat.Message) eventbus.MessageEvent {
+    return eventbus.MessageEvent{
+        Payload: payloadOf(m),
+        Topic: m.Topic,
+        Author: m.Author,
+        Vector: m.Vector,
+        Lamport: int(m.Lamport),
+        Sequence: int(m.Sequence),
+    }
+}
+
+// payloadOf returns the payload of m. Messages from before payloads only
+// have a kind and a text, which are turned into the payload they stand for.
+func payloadOf(m *chat.Message) eventbus.Payload {
+    switch p := m.Payload.(type) {
+    case *chat.Message_Chat:
+        return eventbus.Chat{Text: p.Chat.Text}
+    case *chat.Message_Joined:
+        return eventbus.Join{}
+    case *chat.Message_Left:
+        return eventbus.Leave{}
+    case *chat.Message_Notice:
+        return eventbus.Notice{Text: p.Notice.Text}
+    case *chat.Message_TopicChanged:
+        return eventbus.TopicChange{Owner: p.TopicChanged.Owner, Private: p.TopicChanged.Private}
+    case *chat.Message_Typing:
+        return eventbus.Typing{}
+    }
+    switch m.Kind {
+    case chat.EventKind_JOIN:
+        return eventbus.Join{}
+    case chat.EventKind_LEAVE:
+        return eventbus.Leave{}
+    case chat.EventKind_SYSTEM:
+        return eventbus.Notice{Text: m.Message}
+    case chat.EventKind_TYPING:
+        return eventbus.Typing{}
+    }
+    return eventbus.Chat{Text: m.Message}
+}
+
+// eventLog keeps the events of the bus in a msglog.Log as chat messages.
+type eventLog struct {
+    *msglog.Log
+}
+
+func (l eventLog) Append(d eventbus.MessageEvent) error {
+    return l.Log.Append(message(d))
+}
+
+func (l eventLog) Read(topic string) ([]eventbus.MessageEvent, error) {
+    messages, err := l.Log.Read(topic)
+    if err != nil {
+        return nil, err
+    }
+    events := make([]eventbus.MessageEvent, len(messages))
+    for i, m := range messages {
+        events[i] = eventOf(m)
+    }
+    return events, nil
+}
+
+// session is a running Receive stream and the topics it follows. Changes
+// to the topics go to the EventBus while holding mu, so a stream that is
+// closing can't be subscribed to a topic again.
+type session struct {
+    mu sync.Mutex
+    bus *eventbus.EventBus
+    ch eventbus.DataChannel
+    msg *chat.Request
+    topics []string
+    closed bool
+}
+
+// client is who reads the channel of the session, as the bus knows them.
+func (sess *session) client() eventbus.Client {
+    return eventbus.Client{Author: sess.msg.Author, Lamport: int(sess.msg.Lamport)}
+}
+
+func (sess *session) follows(topic string) int {
+    for i, t := range sess.topics {
+        if t == topic {
+            return i
+        }
+    }
+    return -1
+}
+
+// add makes the stream follow topic from now on. It fails once the channel
+// of the stream was stopped, which ends the stream. The caller holds sess.mu.
+func (sess *session) add(topic string) error {
+    if sess.follows(topic) >= 0 {
+        return nil
+    }
+    if _, err := sess.bus.Subscribe(topic, sess.ch, sess.client()); err != nil {
+        return busError(err)
+    }
+    sess.topics = append(sess.topics, topic)
+    sess.announce(topic, eventbus.Join{})
+    return nil
+}
+
+// busError is the status for an error from subscribing.
+func busError(err error) error {
+    if err == eventbus.ErrClosed {
+        return status.Error(codes.Unavailable, "server is shutting down")
+    }
+    return status.Error(codes.ResourceExhausted, "disconnected for falling too far behind")
+}
+
+// remove makes the stream leave topic. The caller holds sess.mu.
+func (sess *session) remove(topic string) {
+    i := sess.follows(topic)
+    if i < 0 {
+        return
+    }
+    sess.topics = append(sess.topics[:i], sess.topics[i+1:]...)
+    sess.bus.Unsubscribe(topic, sess.ch, sess.client())
+    sess.announce(topic, eventbus.Leave{})
+}
+
+// change follows and leaves the topics in the TopicChange. The whole change
+// is checked before any of it is made, so a rejected change leaves the
+// topics as they were. The last topic can't be left, the stream should be
+// closed instead.
+func (sess *session) change(in *chat.TopicChange) error {
+    for _, topic := range in.Add {
+        if err := pattern.Valid(topic); err != nil {
+            return status.Error(codes.InvalidArgument, err.Error())
+        }
+    }
+    sess.mu.Lock()
+    defer sess.mu.Unlock()
+    if sess.closed {
+        return nil
+    }
+    left := map[string]bool{}
+    for _, topic := range sess.topics {
+        left[topic] = true
+    }
+    for _, topic := range in.Add {
+        left[topic] = true
+    }
+    for _, topic := range in.Remove {
+        delete(left, topic)
+    }
+    if len(left) == 0 {
+        return status.Error(codes.FailedPrecondition, "can't leave the last topic of a stream, close it instead")
+    }
+    for _, topic := range in.Add {
+        if err := sess.add(topic); err != nil {
+            return err
+        }
+    }
+    for _, topic := range in.Remove {
+        sess.remove(topic)
+    }
+    return nil
+}
+
+// close leaves every topic of the stream.
+func (sess *session) close() {
+    sess.mu.Lock()
+    defer sess.mu.Unlock()
+    sess.closed = true
+    for _, topic := range sess.topics {
+        sess.bus.Unsubscribe(topic, sess.ch, sess.client())
+        sess.announce(topic, eventbus.Leave{})
+    }
+}
+
+// announce publishes on topic that the author of the session joined or left
+// it. Nothing is published on patterns like "itu/#", as those are not topics
+// themselves.
+func (sess *session) announce(topic string, payload eventbus.Payload) {
+    if pattern.HasWildcard(topic) {
+        return
+    }
+    sess.bus.Publish(eventbus.MessageEvent{Payload: payload, Topic: topic, Author: sess.msg.Author})
+}
+
+// replayOf is the history msg asks for on topic. A client resuming after the
+// last sequence number it saw gets everything it missed since then, and
+// otherwise what it asks to have replayed: everything stamped after
+// ReplaySince, and of that only the last ReplayLast.
+func replayOf(msg *chat.Request, topic string) eventbus.Replay {
+    after := msg.Resume[topic]
+    if topic == msg.Topic && msg.ResumeAfter > 0 {
+        after = msg.ResumeAfter
+    }
+    if after > 0 {
+        return eventbus.Replay{After: int(after)}
+    }
+    return eventbus.Replay{Since: int(msg.ReplaySince), Last: int(msg.ReplayLast)}
+}
+
+// requestTopics is every topic msg asks for, without duplicates.
+func requestTopics(msg *chat.Request) []string {
+    var topics []string
+    found := map[string]bool{}
+    for _, topic := range append([]string{msg.Topic}, msg.Topics...) {
+        if topic != "" && !found[topic] {
+            found[topic] = true
+            topics = append(topics, topic)
+        }
+    }
+    return topics
+}
+
+// addresses is the -listen flag, which can be given several times or hold
+// several addresses separated by commas.
+type addresses struct {
+    list []string
+    set bool
+}
+
+func (a *addresses) String() string {
+    return strings.Join(a.list, ",")
+}
+
+// Set adds addresses, replacing the default the first time.
+func (a *addresses) Set(value string) error {
+    if !a.set {
+        a.list, a.set = nil, true
+    }
+    for _, address := range strings.Split(value, ",") {
+        if address = strings.TrimSpace(address); address != "" {
+            a.list = append(a.list, address)
+        }
+    }
+    return nil
+}
+
+var listen = &addresses{list: []string{":8080"}}
+var configFile = flag.String("config", "", "file with a line \"name = value\" for each flag to set")
+
+func init() {
+    flag.Var(listen, "listen", "address to listen on, \"host:port\" or \"unix:PATH\" for a Unix domain socket; can be given several times")
+}
+
+// configure fills in the flags that were not given on the command line,
+// first from the environment, where -log is CHAT_LOG and -author-rate is
+// CHAT_AUTHOR_RATE, and then from the -config file.
+func configure(flags *flag.FlagSet) error {
+    given := map[string]bool{}
+    flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+    var err error
+    flags.VisitAll(func(f *flag.Flag) {
+        name := "CHAT_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+        value, found := os.LookupEnv(name)
+        if !found || given[f.Name] || err != nil {
+            return
+        }
+        if err = f.Value.Set(value); err != nil {
+            err = fmt.Errorf("%s: %v", name, err)
+        }
+        given[f.Name] = true
+    })
+    // The file is named by the -config flag of flags.
+    configFile := flags.Lookup("config").Value.String()
+    if err != nil || configFile == "" {
+        return err
+    }
+
+    file, err := os.Open(configFile)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+    scanner := bufio.NewScanner(file)
+    for n := 1; scanner.Scan(); n++ {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        name, value, found := strings.Cut(line, "=")
+        name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+        if !found || flags.Lookup(name) == nil {
+            return fmt.Errorf("%s:%d: expected \"name = value\" with the name of a flag", configFile, n)
+        }
+        if given[name] {
+            continue
+        }
+        if err := flags.Set(name, value); err != nil {
+            return fmt.Errorf("%s:%d: %v", configFile, n, err)
+        }
+    }
+    return scanner.Err()
+}
+
+// listenOn listens on address, which is "host:port", ":port" or "unix:PATH".
+func listenOn(address string) (net.Listener, error) {
+    if !strings.HasPrefix(address, "unix:") {
+        return net.Listen("tcp", address)
+    }
+    // "unix:///tmp/chat.sock" is accepted too, like gRPC clients dial it.
+    path := strings.TrimPrefix(strings.TrimPrefix(address, "unix:"), "//")
+    // A socket left behind by a server that crashed is in the way, but one
+    // that a running server still answers on is not ours to take.
+    if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+        if conn, err := net.Dial("unix", path); err == nil {
+            conn.Close()
+        } else {
+            os.Remove(path)
+        }
+    }
+    return net.Listen("unix", path)
+}
+
+// fail reports why the server can't run and exits with a non-zero status.
+func fail(format string, args ...interface{}) {
+    fmt.Fprintf(os.Stderr, format+"\n", args...)
+    os.Exit(1)
 }
 
 func main()  {
-    lis, err := net.Listen("tcp", ":8080")
+    flag.Parse()
+    if err := configure(flag.CommandLine); err != nil {
+        fail("failed to configure: %v", err)
+    }
+    config := eventbus.Config{Vector: *vector, Retain: *retain, TotalOrder: *totalOrder, Queue: *queue, Logger: log.New(os.Stdout, "", 0)}
+    if *queue < 1 {
+        fail("queue must hold at least one event, got: %v", *queue)
+    }
+    if *retain < 0 {
+        fail("retain can't be negative, got: %v", *retain)
+    }
+    policy, err := eventbus.ParseOverflow(*overflow)
     if err != nil {
-        fmt.Printf("failed to listen: %v", err)
+        fail("%v", err)
+    }
+    config.Overflow = policy
+    var messages *msglog.Log
+    if *logDir != "" {
+        messages, err = msglog.Open(*logDir)
+        if err != nil {
+            fail("failed to open log: %v", err)
+        }
+        config.Log = eventLog{messages}
+    }
+    bus := eventbus.New(config)
+    if err := bus.Restore(); err != nil {
+        fail("failed to read log: %v", err)
     }
     var opts []grpc.ServerOption
+    var unary []grpc.UnaryServerInterceptor
+    var stream []grpc.StreamServerInterceptor
+    if *certFile != "" {
+        config, err := auth.ServerTLS(*certFile, *keyFile, *clientCA)
+        if err != nil {
+            fail("failed to load certificates: %v", err)
+        }
+        opts = append(opts, grpc.Creds(credentials.NewTLS(config)))
+        if *clientCA != "" {
+            unary = append(unary, auth.CertificateUnaryInterceptor())
+            stream = append(stream, auth.CertificateStreamInterceptor())
+        }
+    } else if *clientCA != "" {
+        fail("-client-ca needs -cert and -key")
+    }
+    s := newChatServer(bus)
+    s.log = messages
+    if *dedup > 0 {
+        s.recent = newDedupWindow(*dedup)
+    }
+    if s.acls, err = acl.Open(*aclFile); err != nil {
+        fail("failed to read access lists: %v", err)
+    }
+    if *users != "" {
+        s.users, err = auth.LoadUsers(*users)
+        if err != nil {
+            fail("failed to read users: %v", err)
+        }
+        key := []byte(*secret)
+        if len(key) == 0 {
+            key = make([]byte, 32)
+            rand.Read(key)
+        }
+        s.signer = auth.NewSigner(key, *tokenTTL)
+        unary = append(unary, s.signer.UnaryInterceptor("/chat.Chat/Login"))
+        stream = append(stream, s.signer.StreamInterceptor())
+    }
+    opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
     server := grpc.NewServer(opts...)
-    chat.RegisterChatServer(server, &ChatServer{})
-
+    chat.RegisterChatServer(server, s)
 
-    if err := server.Serve(lis); err != nil {
-        fmt.Printf("failed to serve: %v", err)
+    // Listen only once everything is loaded, so a bad file or flag doesn't
+    // leave a socket behind.
+    var listeners []net.Listener
+    for _, address := range listen.list {
+        lis, err := listenOn(address)
+        if err != nil {
+            for _, lis := range listeners {
+                lis.Close()
+            }
+            fail("failed to listen on %s: %v", address, err)
+        }
+        listeners = append(listeners, lis)
     }
 
+    // Serve returns when a listener breaks, which stops the whole server.
+    errs := make(chan error, len(listeners))
+    for _, lis := range listeners {
+        fmt.Println("Listening on", lis.Addr().Network(), lis.Addr())
+        go func(lis net.Listener) {
+            errs <- server.Serve(lis)
+        }(lis)
+    }
+    quit := make(chan os.Signal, 1)
+    signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+    select {
+    case err := <-errs:
+        server.Stop()
+        fail("failed to serve: %v", err)
+    case sig := <-quit:
+        fmt.Println("Server received", sig, "and is shutting down")
+        s.shutdown(server, *shutdownTimeout)
+    }
 }
 
+// Send publishes a message. When the author or topic is over its rate limit,
+// the retry-after metadata says how many seconds to wait.
 func (s *ChatServer) Send(ctx context.Context, in *chat.Message) (*chat.MessageAck, error) {
-    msg := in.Author+ ": " +in.Message
-    eb.Publish(in.Topic, msg)
-    response := chat.MessageAck{Flag: "OK"}
+    ack, err := s.submit(ctx, in)
+    if wait, ok := retryAfter(err); ok {
+        grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+    }
+    return ack, err
+}
+
+// slowDown is the error for going over a rate limit, with the reason given
+// by format and args. It says how long to wait before trying again.
+func slowDown(wait time.Duration, format string, args ...interface{}) error {
+    st := status.Newf(codes.ResourceExhausted, format+", wait %v", append(args, wait.Round(time.Millisecond))...)
+    if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+        st = detailed
+    }
+    return st.Err()
+}
+
+// retryAfter returns how long to wait before trying again after err, if it says.
+func retryAfter(err error) (time.Duration, bool) {
+    for _, detail := range status.Convert(err).Details() {
+        if info, ok := detail.(*errdetails.RetryInfo); ok {
+            return info.RetryDelay.AsDuration(), true
+        }
+    }
+    return 0, false
+}
+
+// submit publishes a message for Send and Connect. A retry of a message with
+// the same idempotency key gets the ack of the first one, which is the only
+// one published.
+func (s *ChatServer) submit(ctx context.Context, in *chat.Message) (*chat.MessageAck, error) {
+    in.Author = identify(ctx, in.Author)
+    if in.IdempotencyKey == "" || s.recent == nil {
+        return s.send(in)
+    }
+    return s.recent.do(sendKey{in.Author, in.IdempotencyKey}, func() (*chat.MessageAck, error) {
+        return s.send(in)
+    })
+}
+
+func (s *ChatServer) send(in *chat.Message) (*chat.MessageAck, error) {
+    if err := pattern.ValidTopic(in.Topic); err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+    // Clients can only say something, the other events come from the server.
+    switch p := in.Payload.(type) {
+    case nil:
+    case *chat.Message_Chat:
+        if in.Message == "" {
+            in.Message = p.Chat.Text
+        }
+    default:
+        return nil, status.Error(codes.InvalidArgument, "only chat messages can be sent")
+    }
+    if strings.TrimSpace(in.Message) == "" {
+        return nil, status.Error(codes.InvalidArgument, "message is empty")
+    }
+    if len(in.Message) > *maxSize {
+        return nil, status.Errorf(codes.InvalidArgument, "message is %d bytes, at most %d are allowed", len(in.Message), *maxSize)
+    }
+    if !s.acls.CanWrite(in.Topic, in.Author) {
+        return nil, status.Errorf(codes.PermissionDenied, "%s may not write to %s", in.Author, in.Topic)
+    }
+    if !s.bus.Known(in.Topic) {
+        return nil, status.Errorf(codes.NotFound, "nobody has joined topic %s", in.Topic)
+    }
+    if ok, wait := s.authorLimit.Allow(in.Author); !ok {
+        return nil, slowDown(wait, "%s is sending too fast", in.Author)
+    }
+    if ok, wait := s.topicLimit.Allow(in.Topic); !ok {
+        s.authorLimit.Refund(in.Author)
+        return nil, slowDown(wait, "%s is sending too fast", in.Topic)
+    }
+    d, subscribers := s.bus.Publish(eventbus.MessageEvent{Payload: eventbus.Chat{Text: in.Message}, Topic: in.Topic, Author: in.Author, Lamport: int(in.Lamport)})
+    response := chat.MessageAck{Flag: "OK", Id: d.ID(), Lamport: uint64(d.Lamport), Subscribers: uint32(subscribers)}
     return &response, nil
 }
 
-func (s *ChatServer) Receive(msg *chat.Request, stream chat.Chat_ReceiveServer) error {
-    ch := make(chan MessageEvent)
-    eb.Subscribe(msg.Topic, ch, msg)
-    eb.Publish(msg.Topic, msg.Author + " joined")
+// open checks the topics msg asks for and makes a session of them. A session
+// with an id is registered, so Topics can find it, until forget is called.
+func (s *ChatServer) open(msg *chat.Request) (*session, error) {
+    if err := s.accepting(); err != nil {
+        return nil, err
+    }
+    topics := requestTopics(msg)
+    if len(topics) == 0 {
+        return nil, status.Error(codes.InvalidArgument, "no topic to receive")
+    }
+    for _, topic := range topics {
+        if err := pattern.Valid(topic); err != nil {
+            return nil, status.Error(codes.InvalidArgument, err.Error())
+        }
+    }
+    if err := s.readable(topics, msg.Author); err != nil {
+        return nil, err
+    }
+    sess := &session{bus: s.bus, ch: make(eventbus.DataChannel), msg: msg, topics: topics}
+    if msg.Session != "" {
+        s.mu.Lock()
+        defer s.mu.Unlock()
+        if _, found := s.sessions[msg.Session]; found {
+            return nil, status.Error(codes.AlreadyExists, "session is already receiving")
+        }
+        s.sessions[msg.Session] = sess
+    }
+    return sess, nil
+}
+
+// accepting returns an error once the server is shutting down.
+func (s *ChatServer) accepting() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closing {
+        return status.Error(codes.Unavailable, "server is shutting down")
+    }
+    return nil
+}
+
+// shutdown stops the server gracefully. New streams are turned away, every
+// topic is told the server is going down, and the subscribers get what is
+// left in their queues until timeout. Then the log is flushed, the streams
+// are ended and the server waits for the calls still running.
+func (s *ChatServer) shutdown(server *grpc.Server, timeout time.Duration) {
+    deadline := time.Now().Add(timeout)
+    s.mu.Lock()
+    s.closing = true
+    s.mu.Unlock()
+    for _, topic := range s.bus.Topics() {
+        s.bus.Publish(eventbus.MessageEvent{Payload: eventbus.Notice{Text: "server shutting down"}, Topic: topic})
+    }
+    if !s.bus.Drain(deadline) {
+        fmt.Println("Server gave up waiting for slow subscribers")
+    }
+    if s.log != nil {
+        if err := s.log.Sync(); err != nil {
+            fmt.Println("Server failed to flush log:", err)
+        }
+    }
+    s.bus.Close()
+
+    stopped := make(chan struct{})
+    go func() {
+        server.GracefulStop()
+        close(stopped)
+    }()
+    wait := time.Until(deadline)
+    if wait < time.Second {
+        wait = time.Second
+    }
+    select {
+    case <-stopped:
+    case <-time.After(wait):
+        server.Stop()
+    }
+    if s.log != nil {
+        s.log.Close()
+    }
+}
+
+func (s *ChatServer) forget(sess *session) {
+    if sess.msg.Session != "" {
+        s.mu.Lock()
+        delete(s.sessions, sess.msg.Session)
+        s.mu.Unlock()
+    }
+}
+
+// stream subscribes the session and hands every event for it to send until
+// ctx is done: first the history it asks for and then the live events.
+func (s *ChatServer) stream(ctx context.Context, sess *session, send func(d eventbus.MessageEvent) error) error {
+    msg := sess.msg
+    deliver := func(d eventbus.MessageEvent) {
+        // A pattern can match topics the author may not read, and the
+        // author can be banned while receiving.
+        if !s.acls.CanRead(d.Topic, msg.Author) {
+            return
+        }
+        if err := send(d); err == nil {
+            s.bus.Delivered(msg.Author, d)
+            s.receipts.deliver(d, msg.Author)
+        }
+    }
+
+    // Nothing that comes in while the history is sent may be dropped, or
+    // there would be a gap where the history ends.
+    client := sess.client()
+    client.Replaying = true
+    seen, err := s.bus.SubscribeAll(sess.topics, sess.ch, client)
+    if err != nil {
+        return busError(err)
+    }
+    for _, topic := range sess.topics {
+        sess.announce(topic, eventbus.Join{})
+    }
+
+    // Everything up to seen is history and everything after it comes through
+    // ch, so the switch to live delivery neither skips nor repeats. The
+    // history of all topics is merged in the order of the timestamps.
+    var history []eventbus.MessageEvent
+    for topic, upto := range seen {
+        events, err := s.bus.History(topic, upto, replayOf(msg, topic))
+        if err != nil {
+            fmt.Println("failed to replay", topic, "to", msg.Author, ":", err)
+        }
+        history = append(history, events...)
+    }
+    sort.SliceStable(history, func(i, j int) bool { return history[i].Lamport < history[j].Lamport })
+    for _, d := range history {
+        deliver(d)
+    }
+    s.bus.Live(sess.ch)
     for {
         select {
-        case <-stream.Context().Done():
-            eb.Unsubscribe(msg.Topic, ch, msg)
-            eb.Publish(msg.Topic, msg.Author + " left")
+        case <-ctx.Done():
+            sess.close()
             return nil
-        case d := <-ch:
-            stream.Send(&chat.Message{Message: "Lamport timestamp: "+strconv.Itoa(d.lamport_timestamp) +" | "+ d.Data.(string)})
+        case d, ok := <-sess.ch:
+            if !ok {
+                sess.close()
+                if err := s.accepting(); err != nil {
+                    return err
+                }
+                return status.Error(codes.ResourceExhausted, "disconnected for falling too far behind")
+            }
+            deliver(d)
         }
     }
-    return nil
 }
 
+func (s *ChatServer) Receive(msg *chat.Request, stream chat.Chat_ReceiveServer) error {
+    msg.Author = identify(stream.Context(), msg.Author)
+    sess, err := s.open(msg)
+    if err != nil {
+        return err
+    }
+    defer s.forget(sess)
+    return s.stream(stream.Context(), sess, func(d eventbus.MessageEvent) error {
+        // Receive has no way to tell typing apart from messages.
+        if _, typing := d.Payload.(eventbus.Typing); typing {
+            return nil
+        }
+        m := message(d)
+        if *legacy {
+            m.Message = "Lamport timestamp: " + strconv.Itoa(d.Lamport) + " | " + d.Text()
+        }
+        return stream.Send(m)
+    })
+}
+
+// Topics makes the Receive stream of a session follow or leave topics while
+// it runs. A topic that is added only gets the messages from then on.
+func (s *ChatServer) Topics(ctx context.Context, in *chat.TopicChange) (*chat.MessageAck, error) {
+    in.Author = identify(ctx, in.Author)
+    s.mu.Lock()
+    sess, found := s.sessions[in.Session]
+    s.mu.Unlock()
+    if !found || sess.msg.Author != in.Author {
+        return nil, status.Error(codes.NotFound, "no stream is receiving for this session")
+    }
+    if err := s.change(sess, in); err != nil {
+        return nil, err
+    }
+    response := chat.MessageAck{Flag: "OK"}
+    return &response, nil
+}
+
+// Connect does what Send, Receive and Topics do on a single stream. The
+// first frame from the client says what to receive, after which it can send
+// messages, typing notices and topic changes, each answered by a frame with
+// the same ref. Deliveries are sent on the same stream as they come.
+func (s *ChatServer) Connect(stream chat.Chat_ConnectServer) error {
+    first, err := stream.Recv()
+    if err != nil {
+        return err
+    }
+    msg := first.GetHello()
+    if msg == nil {
+        return status.Error(codes.InvalidArgument, "the first frame must be a hello")
+    }
+    msg.Author = identify(stream.Context(), msg.Author)
+    sess, err := s.open(msg)
+    if err != nil {
+        return err
+    }
+    defer s.forget(sess)
+
+    // Replies and deliveries are sent from different goroutines.
+    var mu sync.Mutex
+    send := func(frame *chat.ServerFrame) error {
+        mu.Lock()
+        defer mu.Unlock()
+        return stream.Send(frame)
+    }
+    reply := func(ref uint64, ack *chat.MessageAck, err error) {
+        if err != nil {
+            st := status.Convert(err)
+            wait, _ := retryAfter(err)
+            send(&chat.ServerFrame{Ref: ref, Frame: &chat.ServerFrame_Rejected{Rejected: &chat.Rejected{Code: int32(st.Code()), Reason: st.Message(), RetryAfter: wait.Milliseconds()}}})
+            return
+        }
+        send(&chat.ServerFrame{Ref: ref, Frame: &chat.ServerFrame_Ack{Ack: ack}})
+    }
+
+    ctx, cancel := context.WithCancel(stream.Context())
+    defer cancel()
+    go func() {
+        defer cancel()
+        for {
+            frame, err := stream.Recv()
+            if err != nil {
+                return
+            }
+            switch f := frame.Frame.(type) {
+            case *chat.ClientFrame_Message:
+                f.Message.Author = msg.Author
+                ack, err := s.submit(ctx, f.Message)
+                reply(frame.Ref, ack, err)
+            case *chat.ClientFrame_Typing:
+                if err := pattern.ValidTopic(f.Typing.Topic); err != nil {
+                    reply(frame.Ref, nil, status.Error(codes.InvalidArgument, err.Error()))
+                    continue
+                }
+                if !s.acls.CanWrite(f.Typing.Topic, msg.Author) {
+                    continue
+                }
+                s.bus.Notify(eventbus.MessageEvent{Payload: eventbus.Typing{}, Topic: f.Typing.Topic, Author: msg.Author})
+            case *chat.ClientFrame_Topics:
+                if err := s.change(sess, f.Topics); err != nil {
+                    reply(frame.Ref, nil, err)
+                } else {
+                    reply(frame.Ref, &chat.MessageAck{Flag: "OK"}, nil)
+                }
+            case *chat.ClientFrame_Read:
+                f.Read.Author = msg.Author
+                ack, err := s.Read(ctx, f.Read)
+                reply(frame.Ref, ack, err)
+            default:
+                reply(frame.Ref, nil, status.Error(codes.InvalidArgument, "unexpected frame"))
+            }
+        }
+    }()
+
+    return s.stream(ctx, sess, func(d eventbus.MessageEvent) error {
+        if _, typing := d.Payload.(eventbus.Typing); typing {
+            return send(&chat.ServerFrame{Frame: &chat.ServerFrame_Typing{Typing: &chat.Typing{Author: d.Author, Topic: d.Topic}}})
+        }
+        return send(&chat.ServerFrame{Frame: &chat.ServerFrame_Message{Message: message(d)}})
+    })
+}
+
+// Read records a read receipt. Only a message that was delivered to the
+// author can be read by them.
+func (s *ChatServer) Read(ctx context.Context, in *chat.Receipt) (*chat.MessageAck, error) {
+    in.Author = identify(ctx, in.Author)
+    if err := s.receipts.read(in.Id, in.Author); err != nil {
+        return nil, err
+    }
+    response := chat.MessageAck{Flag: "OK", Id: in.Id}
+    return &response, nil
+}
+
+// Receipts lists who a message was delivered to and who read it.
+func (s *ChatServer) Receipts(ctx context.Context, in *chat.ReceiptQuery) (*chat.ReceiptList, error) {
+    in.Author = identify(ctx, in.Author)
+    // The id is "topic#sequence", and only those who may read the topic can
+    // see who got its messages.
+    i := strings.LastIndex(in.Id, "#")
+    if i < 0 {
+        return nil, status.Error(codes.InvalidArgument, "not a message id: " + in.Id)
+    }
+    if topic := in.Id[:i]; !s.acls.CanRead(topic, in.Author) {
+        return nil, status.Errorf(codes.PermissionDenied, "%s may not read %s", in.Author, topic)
+    }
+    return s.receipts.of(in.Id), nil
+}
+
+// Login gives an author a token for their password. It is the only call
+// that doesn't need a token when logins are required. Every author can only
+// try a few times in a row, so passwords can't be guessed quickly.
+func (s *ChatServer) Login(ctx context.Context, in *chat.Credentials) (*chat.Token, error) {
+    if s.signer == nil {
+        return nil, status.Error(codes.FailedPrecondition, "this server does not use logins")
+    }
+    if ok, wait := s.loginLimit.Allow(in.Author); !ok {
+        grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+        return nil, slowDown(wait, "too many logins as %s", in.Author)
+    }
+    if !s.users.Check(in.Author, in.Password) {
+        return nil, status.Error(codes.Unauthenticated, "wrong name or password")
+    }
+    token, expires := s.signer.Issue(in.Author)
+    return &chat.Token{Token: token, Expires: expires.UnixMilli()}, nil
+}
+
+// accessList converts the list of topic to what the Access gRPC returns.
+func accessList(topic string, l acl.List) *chat.AccessList {
+    return &chat.AccessList{
+        Topic: topic,
+        Owner: l.Owner,
+        Private: l.Private,
+        Members: l.With(acl.Member),
+        ReadOnly: l.With(acl.ReadOnly),
+        Banned: l.With(acl.Banned),
+    }
+}
+
+// Access shows and changes who may read and write a topic. Anyone who may
+// read the topic can see the list, only the owner can change it.
+func (s *ChatServer) Access(ctx context.Context, in *chat.AccessChange) (*chat.AccessList, error) {
+    in.Author = identify(ctx, in.Author)
+    if err := pattern.ValidTopic(in.Topic); err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+    if len(in.Members)+len(in.ReadOnly)+len(in.Banned)+len(in.Remove) == 0 && in.Visibility == chat.Visibility_UNCHANGED && in.Owner == "" {
+        if !s.acls.CanRead(in.Topic, in.Author) {
+            return nil, status.Errorf(codes.PermissionDenied, "%s may not read %s", in.Author, in.Topic)
+        }
+        l, _ := s.acls.Get(in.Topic)
+        return accessList(in.Topic, l), nil
+    }
+
+    // Only a topic nobody else uses yet can be claimed, unless by an admin.
+    mayClaim := s.admins[in.Author] || !s.bus.Used(in.Topic, in.Author)
+    l, err := s.acls.Change(in.Topic, in.Author, mayClaim, func(l *acl.List) error {
+        roles := []struct {
+            names []string
+            role acl.Role
+        }{{in.Remove, acl.None}, {in.Members, acl.Member}, {in.ReadOnly, acl.ReadOnly}, {in.Banned, acl.Banned}}
+        for _, r := range roles {
+            for _, name := range r.names {
+                if err := l.Set(name, r.role); err != nil {
+                    return status.Error(codes.InvalidArgument, err.Error())
+                }
+            }
+        }
+        switch in.Visibility {
+        case chat.Visibility_OPEN:
+            l.Private = false
+        case chat.Visibility_PRIVATE:
+            l.Private = true
+        }
+        if in.Owner != "" {
+            if err := l.Set(in.Owner, acl.Owner); err != nil {
+                return status.Error(codes.InvalidArgument, err.Error())
+            }
+        }
+        return nil
+    })
+    if err == acl.ErrNotOwner || err == acl.ErrInUse {
+        return nil, status.Error(codes.PermissionDenied, err.Error())
+    }
+    if err != nil {
+        if _, ok := status.FromError(err); !ok {
+            fmt.Println("failed to save access lists:", err)
+            err = status.Error(codes.Internal, "failed to save the access list")
+        }
+        return nil, err
+    }
+    if in.Visibility != chat.Visibility_UNCHANGED || in.Owner != "" {
+        s.bus.Publish(eventbus.MessageEvent{Payload: eventbus.TopicChange{Owner: l.Owner, Private: l.Private}, Topic: in.Topic, Author: in.Author})
+    }
+    return accessList(in.Topic, l), nil
+}
@@ -0,0 +1,968 @@
+package main
+
+import (
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "flag"
+    "fmt"
+    "math/big"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/AndersStendevad/disys-m3/auth"
+    "github.com/AndersStendevad/disys-m3/eventbus"
+    chat "github.com/AndersStendevad/disys-m3/grpc"
+    "github.com/AndersStendevad/disys-m3/msglog"
+    "github.com/AndersStendevad/disys-m3/ratelimit"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+)
+
+func TestSendAcksAndRejects(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    ch := make(eventbus.DataChannel)
+    bus.Subscribe("itu", ch, eventbus.Client{Author: "Emil"})
+
+    ack, err := s.Send(context.Background(), &chat.Message{Author: "Anders", Topic: "itu", Message: "hi"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    d := <-ch
+    if ack.Id != d.ID() || ack.Id != "itu#1" || ack.Lamport != uint64(d.Lamport) || ack.Subscribers != 1 {
+        t.Fatalf("ack %v for %v", ack, d)
+    }
+    if d.Payload != (eventbus.Chat{Text: "hi"}) {
+        t.Fatalf("published %v", d.Payload)
+    }
+
+    // A client can send the text as a chat payload too.
+    if _, err := s.Send(context.Background(), &chat.Message{Author: "Anders", Topic: "itu", Payload: &chat.Message_Chat{Chat: &chat.ChatMessage{Text: "hello"}}}); err != nil {
+        t.Fatal(err)
+    }
+    if d := <-ch; d.Text() != "Anders: hello" {
+        t.Fatalf("published %q", d.Text())
+    }
+
+    for _, c := range []struct {
+        message *chat.Message
+        code codes.Code
+    }{
+        {&chat.Message{Author: "Anders", Topic: "itu", Message: " "}, codes.InvalidArgument},
+        {&chat.Message{Author: "Anders", Topic: "itu", Message: strings.Repeat("a", *maxSize+1)}, codes.InvalidArgument},
+        {&chat.Message{Author: "Anders", Topic: "nowhere", Message: "hi"}, codes.NotFound},
+        {&chat.Message{Author: "Anders", Topic: "itu/#", Message: "hi"}, codes.InvalidArgument},
+        {&chat.Message{Author: "Anders", Topic: "itu", Message: "hi", Payload: &chat.Message_Joined{Joined: &chat.Joined{}}}, codes.InvalidArgument},
+    } {
+        if _, err := s.Send(context.Background(), c.message); status.Code(err) != c.code {
+            t.Errorf("sending %.20q to %s: got %v, want %v", c.message.Message, c.message.Topic, err, c.code)
+        }
+    }
+}
+
+func TestRetriedSendIsPublishedOnce(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    s.recent = newDedupWindow(time.Minute)
+    ch := make(eventbus.DataChannel)
+    bus.Subscribe("itu", ch, eventbus.Client{Author: "Emil"})
+
+    // The retries race the first Send.
+    acks := make([]*chat.MessageAck, 5)
+    var wg sync.WaitGroup
+    for i := range acks {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            ack, err := s.Send(context.Background(), &chat.Message{Author: "Anders", Topic: "itu", Message: "hi", IdempotencyKey: "1"})
+            if err != nil {
+                t.Error(err)
+            }
+            acks[i] = ack
+        }(i)
+    }
+    wg.Wait()
+    for _, ack := range acks {
+        if ack.Id != acks[0].Id {
+            t.Fatalf("retries were acked as %s and %s", acks[0].Id, ack.Id)
+        }
+    }
+
+    // The same key from someone else is another message.
+    ack, err := s.Send(context.Background(), &chat.Message{Author: "Sebastian", Topic: "itu", Message: "hi", IdempotencyKey: "1"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ack.Id == acks[0].Id {
+        t.Fatalf("Sebastian's message was taken for a retry of Anders'")
+    }
+    if first, second := <-ch, <-ch; first.Author != "Anders" || second.Author != "Sebastian" {
+        t.Fatalf("got %v and %v", first, second)
+    }
+    select {
+    case d := <-ch:
+        t.Fatalf("%v was published twice", d)
+    case <-time.After(50 * time.Millisecond):
+    }
+}
+
+func TestReceipts(t *testing.T) {
+    r := newReceipts(2)
+    first := eventbus.MessageEvent{Payload: eventbus.Chat{Text: "hi"}, Topic: "itu", Author: "Anders", Sequence: 1}
+    r.deliver(first, "Anders")
+    r.deliver(first, "Emil")
+    r.deliver(first, "Sebastian")
+    r.deliver(eventbus.MessageEvent{Payload: eventbus.Join{}, Topic: "itu", Author: "Emil", Sequence: 2}, "Anders")
+
+    if err := r.read(first.ID(), "Emil"); err != nil {
+        t.Fatal(err)
+    }
+    if err := r.read(first.ID(), "Anders"); status.Code(err) != codes.NotFound {
+        t.Fatalf("Anders could read their own message: %v", err)
+    }
+    list := r.of(first.ID())
+    if len(list.Delivered) != 2 || len(list.Read) != 1 || list.Read[0].Author != "Emil" {
+        t.Fatalf("got %v", list)
+    }
+    if list := r.of("itu#2"); len(list.Delivered) != 0 {
+        t.Fatalf("the join got receipts: %v", list)
+    }
+
+    // Only the last two messages are kept.
+    for sequence := 3; sequence <= 4; sequence++ {
+        r.deliver(eventbus.MessageEvent{Payload: eventbus.Chat{Text: "hi"}, Topic: "itu", Author: "Anders", Sequence: sequence}, "Emil")
+    }
+    if list := r.of(first.ID()); len(list.Delivered) != 0 {
+        t.Fatalf("receipts of %s were kept: %v", first.ID(), list)
+    }
+}
+
+// serve runs s on a free local port until the test ends.
+func serve(t *testing.T, s *ChatServer, opts ...grpc.ServerOption) string {
+    t.Helper()
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    server := grpc.NewServer(opts...)
+    chat.RegisterChatServer(server, s)
+    go server.Serve(lis)
+    t.Cleanup(server.Stop)
+    return lis.Addr().String()
+}
+
+func TestLoginDecidesTheAuthor(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    hash, err := auth.HashPassword("secret")
+    if err != nil {
+        t.Fatal(err)
+    }
+    dir := t.TempDir()
+    malformed := filepath.Join(dir, "malformed.txt")
+    os.WriteFile(malformed, []byte("Anders:"+string(hash)+"\nEmil\n"), 0644)
+    if _, err := auth.LoadUsers(malformed); err == nil || !strings.Contains(err.Error(), "malformed.txt:2:") {
+        t.Fatalf("loaded a line without a hash: %v", err)
+    }
+    path := filepath.Join(dir, "users.txt")
+    os.WriteFile(path, []byte("# users\nAnders:"+string(hash)+"\n"), 0644)
+    s.users, err = auth.LoadUsers(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    s.loginLimit = ratelimit.New(0.001, 2)
+    s.signer = auth.NewSigner([]byte("key"), time.Minute)
+    addr := serve(t, s, grpc.UnaryInterceptor(s.signer.UnaryInterceptor("/chat.Chat/Login")), grpc.StreamInterceptor(s.signer.StreamInterceptor()))
+
+    token := &auth.Token{}
+    conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithPerRPCCredentials(token))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+    ctx := context.Background()
+    ch := make(eventbus.DataChannel)
+    bus.Subscribe("itu", ch, eventbus.Client{Author: "Emil"})
+
+    if _, err := client.Send(ctx, &chat.Message{Author: "Anders", Topic: "itu", Message: "hi"}); status.Code(err) != codes.Unauthenticated {
+        t.Fatalf("sent without logging in: %v", err)
+    }
+    if _, err := client.Login(ctx, &chat.Credentials{Author: "Anders", Password: "wrong"}); status.Code(err) != codes.Unauthenticated {
+        t.Fatalf("logged in with the wrong password: %v", err)
+    }
+    if _, err := client.Login(ctx, &chat.Credentials{Author: "Emil", Password: "secret"}); status.Code(err) != codes.Unauthenticated {
+        t.Fatalf("logged in as someone unknown: %v", err)
+    }
+    login, err := client.Login(ctx, &chat.Credentials{Author: "Anders", Password: "secret"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    token.Set(login.Token)
+
+    // Anders can't pretend to be Emil.
+    if _, err := client.Send(ctx, &chat.Message{Author: "Emil", Topic: "itu", Message: "hi"}); err != nil {
+        t.Fatal(err)
+    }
+    if d := <-ch; d.Author != "Anders" {
+        t.Fatalf("message was sent as %s", d.Author)
+    }
+
+    // After two tries Anders has to wait, even with the right password.
+    var trailer metadata.MD
+    if _, err := client.Login(ctx, &chat.Credentials{Author: "Anders", Password: "secret"}, grpc.Trailer(&trailer)); status.Code(err) != codes.ResourceExhausted || len(trailer.Get("retry-after")) == 0 {
+        t.Fatalf("logged in too often: %v, %v", err, trailer)
+    }
+
+    token.Set(login.Token + "x")
+    if _, err := client.Send(ctx, &chat.Message{Author: "Anders", Topic: "itu", Message: "hi"}); status.Code(err) != codes.Unauthenticated {
+        t.Fatalf("sent with a forged token: %v", err)
+    }
+}
+
+// certificate writes a certificate for name and its key to dir. It is signed
+// by parent, or by itself when parent is nil, which makes it a CA.
+func certificate(t *testing.T, dir string, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(time.Now().UnixNano()),
+        Subject: pkix.Name{CommonName: name},
+        NotBefore: time.Now().Add(-time.Hour),
+        NotAfter: time.Now().Add(time.Hour),
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+        IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+    }
+    if parent == nil {
+        template.IsCA = true
+        template.BasicConstraintsValid = true
+        template.KeyUsage = x509.KeyUsageCertSign
+        parent, parentKey = template, key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    keyDER, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+    os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return cert, key
+}
+
+func TestTLS(t *testing.T) {
+    dir := t.TempDir()
+    ca, caKey := certificate(t, dir, "ca", nil, nil)
+    certificate(t, dir, "server", ca, caKey)
+    certificate(t, dir, "Anders", ca, caKey)
+    other, otherKey := certificate(t, dir, "other-ca", nil, nil)
+    certificate(t, dir, "Emil", other, otherKey)
+    file := func(name string) string { return filepath.Join(dir, name) }
+
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+
+    // dial sends a message as Emil and returns who it was published as.
+    dial := func(addr string, config *tls.Config) (string, error) {
+        ch := make(eventbus.DataChannel)
+        bus.Subscribe("itu", ch, eventbus.Client{Author: "Sebastian"})
+        conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+        if err != nil {
+            return "", err
+        }
+        defer conn.Close()
+        if _, err := chat.NewChatClient(conn).Send(context.Background(), &chat.Message{Author: "Emil", Topic: "itu", Message: "hi"}); err != nil {
+            return "", err
+        }
+        return (<-ch).Author, nil
+    }
+
+    t.Run("server only", func(t *testing.T) {
+        config, err := auth.ServerTLS(file("server.crt"), file("server.key"), "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        addr := serve(t, newChatServer(bus), grpc.Creds(credentials.NewTLS(config)))
+
+        trusting, err := auth.ClientTLS(file("ca.crt"), "", "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if author, err := dial(addr, trusting); err != nil || author != "Emil" {
+            t.Fatalf("sent as %q: %v", author, err)
+        }
+        distrusting, err := auth.ClientTLS(file("other-ca.crt"), "", "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := dial(addr, distrusting); err == nil {
+            t.Fatal("client trusted a server certificate from another CA")
+        }
+    })
+
+    t.Run("mutual", func(t *testing.T) {
+        config, err := auth.ServerTLS(file("server.crt"), file("server.key"), file("ca.crt"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        addr := serve(t, newChatServer(bus), grpc.Creds(credentials.NewTLS(config)), grpc.UnaryInterceptor(auth.CertificateUnaryInterceptor()))
+
+        // The common name decides the author, not the message.
+        anders, err := auth.ClientTLS(file("ca.crt"), file("Anders.crt"), file("Anders.key"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if author, err := dial(addr, anders); err != nil || author != "Anders" {
+            t.Fatalf("sent as %q: %v", author, err)
+        }
+
+        anonymous, err := auth.ClientTLS(file("ca.crt"), "", "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := dial(addr, anonymous); err == nil {
+            t.Fatal("sent without a client certificate")
+        }
+        emil, err := auth.ClientTLS(file("ca.crt"), file("Emil.crt"), file("Emil.key"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := dial(addr, emil); err == nil {
+            t.Fatal("sent with a client certificate from another CA")
+        }
+    })
+}
+
+func TestAccessLists(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    ctx := context.Background()
+    ch := make(eventbus.DataChannel)
+    bus.Subscribe("team", ch, eventbus.Client{Author: "Anders"})
+
+    // Anders claims the topic by being the first to change it.
+    _, err := s.Access(ctx, &chat.AccessChange{Author: "Anders", Topic: "team", Visibility: chat.Visibility_PRIVATE, Members: []string{"Emil"}, ReadOnly: []string{"Sebastian"}, Banned: []string{"Eve"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Emil", Topic: "team", Members: []string{"Eve"}}); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("a member changed the list: %v", err)
+    }
+    list, err := s.Access(ctx, &chat.AccessChange{Author: "Sebastian", Topic: "team"})
+    if err != nil || list.Owner != "Anders" || !list.Private || len(list.Members) != 1 || len(list.ReadOnly) != 1 || len(list.Banned) != 1 {
+        t.Fatalf("got %v: %v", list, err)
+    }
+
+    for _, c := range []struct {
+        author string
+        read, write bool
+    }{{"Anders", true, true}, {"Emil", true, true}, {"Sebastian", true, false}, {"Eve", false, false}, {"Mallory", false, false}} {
+        _, err := s.open(&chat.Request{Author: c.author, Topic: "team"})
+        if (err == nil) != c.read {
+            t.Errorf("%s receiving: %v", c.author, err)
+        }
+        _, err = s.Send(ctx, &chat.Message{Author: c.author, Topic: "team", Message: "hi"})
+        if (err == nil) != c.write || (err != nil && status.Code(err) != codes.PermissionDenied) {
+            t.Errorf("%s sending: %v", c.author, err)
+        }
+    }
+
+    // Handing the topic over leaves Anders a member, and opening it lets
+    // everyone but the banned in.
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Anders", Topic: "team", Owner: "Emil", Visibility: chat.Visibility_OPEN}); err != nil {
+        t.Fatal(err)
+    }
+    list, _ = s.Access(ctx, &chat.AccessChange{Author: "Mallory", Topic: "team"})
+    if list.Owner != "Emil" || list.Private || list.Members[0] != "Anders" {
+        t.Fatalf("got %v", list)
+    }
+    if _, err := s.Send(ctx, &chat.Message{Author: "Mallory", Topic: "team", Message: "hi"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.open(&chat.Request{Author: "Eve", Topic: "team"}); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("Eve could receive: %v", err)
+    }
+
+    // Only those who may read a topic see the receipts of its messages.
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Emil", Topic: "team", Visibility: chat.Visibility_PRIVATE}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.Receipts(ctx, &chat.ReceiptQuery{Author: "Mallory", Id: "team#1"}); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("Mallory saw the receipts of a private topic: %v", err)
+    }
+    if _, err := s.Receipts(ctx, &chat.ReceiptQuery{Author: "Anders", Id: "team#1"}); err != nil {
+        t.Fatal(err)
+    }
+
+    // A topic others follow or talk on can't be claimed, except by an admin.
+    bus.Subscribe("itu", make(eventbus.DataChannel), eventbus.Client{Author: "Sebastian"})
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Eve", Topic: "itu", Visibility: chat.Visibility_PRIVATE}); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("Eve claimed a busy topic: %v", err)
+    }
+    bus.Publish(eventbus.MessageEvent{Payload: eventbus.Chat{Text: "hi"}, Topic: "ku", Author: "Sebastian"})
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Eve", Topic: "ku", Visibility: chat.Visibility_PRIVATE}); status.Code(err) != codes.PermissionDenied {
+        t.Fatalf("Eve claimed a topic Sebastian talked on: %v", err)
+    }
+    s.admins["Root"] = true
+    if _, err := s.Access(ctx, &chat.AccessChange{Author: "Root", Topic: "itu", Visibility: chat.Visibility_PRIVATE}); err != nil {
+        t.Fatalf("an admin could not claim a busy topic: %v", err)
+    }
+}
+
+func TestRateLimit(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    s.authorLimit = ratelimit.New(1, 2)
+    s.topicLimit = ratelimit.New(1, 3)
+    conn, err := grpc.Dial(serve(t, s), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+    bus.Subscribe("itu", make(eventbus.DataChannel), eventbus.Client{Author: "Emil"})
+
+    send := func(author string) (string, error) {
+        var trailer metadata.MD
+        _, err := client.Send(context.Background(), &chat.Message{Author: author, Topic: "itu", Message: "hi"}, grpc.Trailer(&trailer))
+        if retry := trailer.Get("retry-after"); len(retry) > 0 {
+            return retry[0], err
+        }
+        return "", err
+    }
+    for i := 0; i < 2; i++ {
+        if _, err := send("Anders"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    retry, err := send("Anders")
+    if status.Code(err) != codes.ResourceExhausted || retry != "1" {
+        t.Fatalf("third message in a burst of two: retry-after %q, %v", retry, err)
+    }
+
+    // Emil has a bucket of their own, but the topic only takes one more.
+    if _, err := send("Emil"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := send("Emil"); status.Code(err) != codes.ResourceExhausted {
+        t.Fatalf("topic took a fourth message: %v", err)
+    }
+    // That didn't cost Emil a token.
+    if ok, _ := s.authorLimit.Allow("Emil"); !ok {
+        t.Fatal("a message the topic turned away was counted against Emil")
+    }
+}
+
+func TestShutdownTellsSubscribers(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    server := grpc.NewServer()
+    chat.RegisterChatServer(server, s)
+    go server.Serve(lis)
+    t.Cleanup(server.Stop)
+    conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+
+    stream, err := client.Receive(context.Background(), &chat.Request{Author: "Emil", Topic: "itu"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if m, err := stream.Recv(); err != nil || m.Kind != chat.EventKind_JOIN {
+        t.Fatalf("got %v, %v", m, err)
+    }
+    if _, err := client.Send(context.Background(), &chat.Message{Author: "Anders", Topic: "itu", Message: "bye"}); err != nil {
+        t.Fatal(err)
+    }
+
+    done := make(chan struct{})
+    go func() {
+        s.shutdown(server, 5*time.Second)
+        close(done)
+    }()
+    // Everything that was queued arrives before the notice, and then the stream ends.
+    if m, err := stream.Recv(); err != nil || m.Message != "bye" {
+        t.Fatalf("got %v, %v", m, err)
+    }
+    if m, err := stream.Recv(); err != nil || m.GetNotice().GetText() != "server shutting down" || m.Kind != chat.EventKind_SYSTEM {
+        t.Fatalf("got %v, %v", m, err)
+    }
+    if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+        t.Fatalf("stream ended with %v", err)
+    }
+    select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("shutdown did not finish")
+    }
+    if _, err := s.open(&chat.Request{Author: "Sebastian", Topic: "itu"}); status.Code(err) != codes.Unavailable {
+        t.Fatalf("subscribed after shutdown: %v", err)
+    }
+}
+
+func TestConnect(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    conn, err := grpc.Dial(serve(t, s), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    // connect says hello as author and returns a function that reads frames
+    // until one matches.
+    connect := func(author string, topics ...string) (chat.Chat_ConnectClient, func(match func(*chat.ServerFrame) bool) *chat.ServerFrame) {
+        stream, err := client.Connect(ctx)
+        if err != nil {
+            t.Fatal(err)
+        }
+        hello := &chat.Request{Author: author, Topic: topics[0], Topics: topics[1:]}
+        if err := stream.Send(&chat.ClientFrame{Frame: &chat.ClientFrame_Hello{Hello: hello}}); err != nil {
+            t.Fatal(err)
+        }
+        return stream, func(match func(*chat.ServerFrame) bool) *chat.ServerFrame {
+            t.Helper()
+            for {
+                frame, err := stream.Recv()
+                if err != nil {
+                    t.Fatalf("%s: %v", author, err)
+                }
+                if match(frame) {
+                    return frame
+                }
+            }
+        }
+    }
+    said := func(author string, text string) func(*chat.ServerFrame) bool {
+        return func(f *chat.ServerFrame) bool {
+            m := f.GetMessage()
+            return m != nil && m.Author == author && m.GetChat().GetText() == text
+        }
+    }
+    answer := func(ref uint64) func(*chat.ServerFrame) bool {
+        return func(f *chat.ServerFrame) bool { return f.Ref == ref }
+    }
+
+    anders, andersNext := connect("Anders", "itu")
+    andersNext(func(f *chat.ServerFrame) bool { return f.GetMessage().GetJoined() != nil })
+    emil, emilNext := connect("Emil", "itu")
+    emilNext(func(f *chat.ServerFrame) bool { return f.GetMessage().GetJoined() != nil && f.GetMessage().Author == "Emil" })
+
+    // Every frame is answered with an ack or a rejection carrying its ref.
+    message := &chat.Message{Author: "Anders", Topic: "itu", Message: "hi"}
+    anders.Send(&chat.ClientFrame{Ref: 1, Frame: &chat.ClientFrame_Message{Message: message}})
+    if ack := andersNext(answer(1)).GetAck(); ack == nil || ack.Id == "" {
+        t.Fatal("message was not acked")
+    }
+    emilNext(said("Anders", "hi"))
+    empty := &chat.Message{Author: "Anders", Topic: "itu", Message: " "}
+    anders.Send(&chat.ClientFrame{Ref: 2, Frame: &chat.ClientFrame_Message{Message: empty}})
+    if rejected := andersNext(answer(2)).GetRejected(); rejected == nil || codes.Code(rejected.Code) != codes.InvalidArgument {
+        t.Fatal("empty message was not rejected")
+    }
+
+    // The author of a frame is the author of the stream, whatever it says.
+    forged := &chat.Message{Author: "Sebastian", Topic: "itu", Message: "forged"}
+    anders.Send(&chat.ClientFrame{Ref: 3, Frame: &chat.ClientFrame_Message{Message: forged}})
+    emilNext(said("Anders", "forged"))
+
+    // Typing notices go to the others on the topic.
+    anders.Send(&chat.ClientFrame{Ref: 4, Frame: &chat.ClientFrame_Typing{Typing: &chat.Typing{Author: "Anders", Topic: "itu"}}})
+    if typing := emilNext(func(f *chat.ServerFrame) bool { return f.GetTyping() != nil }).GetTyping(); typing.Author != "Anders" {
+        t.Fatalf("got typing %v", typing)
+    }
+
+    // Topic changes are acked, and a change that would leave no topic is
+    // rejected without changing anything.
+    anders.Send(&chat.ClientFrame{Ref: 5, Frame: &chat.ClientFrame_Topics{Topics: &chat.TopicChange{Add: []string{"dtu"}}}})
+    if andersNext(answer(5)).GetAck() == nil {
+        t.Fatal("adding dtu was not acked")
+    }
+    anders.Send(&chat.ClientFrame{Ref: 6, Frame: &chat.ClientFrame_Topics{Topics: &chat.TopicChange{Remove: []string{"itu", "dtu"}}}})
+    if rejected := andersNext(answer(6)).GetRejected(); rejected == nil || codes.Code(rejected.Code) != codes.FailedPrecondition {
+        t.Fatal("leaving every topic was not rejected")
+    }
+    emil.Send(&chat.ClientFrame{Ref: 1, Frame: &chat.ClientFrame_Message{Message: &chat.Message{Topic: "itu", Message: "still there?"}}})
+    andersNext(said("Emil", "still there?"))
+    emil.Send(&chat.ClientFrame{Ref: 2, Frame: &chat.ClientFrame_Message{Message: &chat.Message{Topic: "dtu", Message: "and here?"}}})
+    andersNext(said("Emil", "and here?"))
+
+    // The first frame has to be a hello.
+    stream, err := client.Connect(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stream.Send(&chat.ClientFrame{Ref: 1, Frame: &chat.ClientFrame_Message{Message: message}})
+    if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+        t.Fatalf("got %v", err)
+    }
+}
+
+func TestTopics(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    conn, err := grpc.Dial(serve(t, s), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    stream, err := client.Receive(ctx, &chat.Request{Author: "Emil", Topic: "itu", Session: "emil-1"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The session is registered by the time the stream says Emil joined.
+    if m, err := stream.Recv(); err != nil || m.Kind != chat.EventKind_JOIN {
+        t.Fatalf("got %v, %v", m, err)
+    }
+    // next returns the next chat message on the stream.
+    next := func() *chat.Message {
+        t.Helper()
+        for {
+            m, err := stream.Recv()
+            if err != nil {
+                t.Fatal(err)
+            }
+            if m.Kind == chat.EventKind_MESSAGE {
+                return m
+            }
+        }
+    }
+    send := func(topic string, text string) {
+        t.Helper()
+        if _, err := client.Send(ctx, &chat.Message{Author: "Anders", Topic: topic, Message: text}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    for _, c := range []struct {
+        name   string
+        change *chat.TopicChange
+        code   codes.Code
+    }{
+        {"unknown session", &chat.TopicChange{Author: "Emil", Session: "emil-2", Add: []string{"dtu"}}, codes.NotFound},
+        {"someone else's session", &chat.TopicChange{Author: "Anders", Session: "emil-1", Add: []string{"dtu"}}, codes.NotFound},
+        {"invalid topic", &chat.TopicChange{Author: "Emil", Session: "emil-1", Add: []string{"itu/#/x"}}, codes.InvalidArgument},
+        {"leaving every topic", &chat.TopicChange{Author: "Emil", Session: "emil-1", Remove: []string{"itu"}}, codes.FailedPrecondition},
+    } {
+        if _, err := client.Topics(ctx, c.change); status.Code(err) != c.code {
+            t.Errorf("%s: got %v, want %v", c.name, err, c.code)
+        }
+    }
+
+    // A topic added to the running stream gets the messages from then on.
+    if _, err := client.Topics(ctx, &chat.TopicChange{Author: "Emil", Session: "emil-1", Add: []string{"dtu"}}); err != nil {
+        t.Fatal(err)
+    }
+    send("dtu", "hej")
+    if m := next(); m.Topic != "dtu" || m.Message != "hej" {
+        t.Fatalf("got %v", m)
+    }
+
+    // A removed one gets nothing more, while the others carry on.
+    if _, err := client.Topics(ctx, &chat.TopicChange{Author: "Emil", Session: "emil-1", Remove: []string{"itu"}}); err != nil {
+        t.Fatal(err)
+    }
+    send("itu", "gone")
+    send("dtu", "still here")
+    if m := next(); m.Topic != "dtu" || m.Message != "still here" {
+        t.Fatalf("got %v", m)
+    }
+}
+
+func TestPayloadsSurviveTheLog(t *testing.T) {
+    messages, err := msglog.Open(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer messages.Close()
+    logged := eventLog{messages}
+    payloads := []eventbus.Payload{
+        eventbus.Chat{Text: "hi"},
+        eventbus.Join{},
+        eventbus.Leave{},
+        eventbus.Notice{Text: "server shutting down"},
+        eventbus.TopicChange{Owner: "Emil", Private: true},
+        eventbus.Typing{},
+    }
+    for i, p := range payloads {
+        d := eventbus.MessageEvent{Payload: p, Topic: "itu", Author: "Anders", Lamport: 3, Sequence: i + 1}
+        if m := message(d); m.Message == "" || m.Id != d.ID() {
+            t.Errorf("%T became %v", p, m)
+        }
+        if err := logged.Append(d); err != nil {
+            t.Fatal(err)
+        }
+    }
+    events, err := logged.Read("itu")
+    if err != nil || len(events) != len(payloads) {
+        t.Fatalf("read %d events: %v", len(events), err)
+    }
+    for i, d := range events {
+        if d.Payload != payloads[i] || d.Sequence != i+1 || d.Author != "Anders" {
+            t.Errorf("%T came back as %#v", payloads[i], d)
+        }
+    }
+
+    // Messages logged before payloads only have a kind and a text.
+    for _, c := range []struct {
+        m *chat.Message
+        want eventbus.Payload
+    }{
+        {&chat.Message{Author: "Anders", Message: "hi"}, eventbus.Chat{Text: "hi"}},
+        {&chat.Message{Author: "Anders", Message: "Anders joined", Kind: chat.EventKind_JOIN}, eventbus.Join{}},
+        {&chat.Message{Author: "Anders", Message: "Anders left", Kind: chat.EventKind_LEAVE}, eventbus.Leave{}},
+        {&chat.Message{Message: "server shutting down", Kind: chat.EventKind_SYSTEM}, eventbus.Notice{Text: "server shutting down"}},
+    } {
+        if got := payloadOf(c.m); got != c.want {
+            t.Errorf("%v came back as %#v", c.m, got)
+        }
+    }
+
+    // An event without a payload can still be streamed.
+    if m := message(eventbus.MessageEvent{Topic: "itu"}); m.Kind != chat.EventKind_MESSAGE || m.GetChat() == nil {
+        t.Errorf("got %v", m)
+    }
+}
+
+func TestConfigure(t *testing.T) {
+    dir := t.TempDir()
+    // file writes a config file and returns its name.
+    file := func(name string, lines ...string) string {
+        path := filepath.Join(dir, name)
+        if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+            t.Fatal(err)
+        }
+        return path
+    }
+    retainFile := file("retain.conf", "# retain from the file", "retain = 7", "listen = 127.0.0.1:1, unix:/tmp/chat.sock")
+    badFile := file("bad.conf", "retian = 7")
+
+    for _, c := range []struct {
+        name string
+        args []string
+        env map[string]string
+        retain int
+        listen string
+        author float64
+        fails bool
+    }{
+        {name: "defaults", retain: 100, listen: ":8080", author: 5},
+        {name: "flag", args: []string{"-retain", "9", "-listen", "127.0.0.1:2"}, retain: 9, listen: "127.0.0.1:2", author: 5},
+        {name: "env", env: map[string]string{"CHAT_RETAIN": "8", "CHAT_AUTHOR_RATE": "0.5"}, retain: 8, listen: ":8080", author: 0.5},
+        {name: "file", args: []string{"-config", retainFile}, retain: 7, listen: "127.0.0.1:1,unix:/tmp/chat.sock", author: 5},
+        {name: "file named in env", env: map[string]string{"CHAT_CONFIG": retainFile}, retain: 7, listen: "127.0.0.1:1,unix:/tmp/chat.sock", author: 5},
+        {name: "env before file", args: []string{"-config", retainFile}, env: map[string]string{"CHAT_RETAIN": "8"}, retain: 8, listen: "127.0.0.1:1,unix:/tmp/chat.sock", author: 5},
+        {name: "flag before env and file", args: []string{"-config", retainFile, "-retain", "9"}, env: map[string]string{"CHAT_RETAIN": "8", "CHAT_LISTEN": "127.0.0.1:3"}, retain: 9, listen: "127.0.0.1:3", author: 5},
+        {name: "bad env", env: map[string]string{"CHAT_RETAIN": "many"}, fails: true},
+        {name: "unknown name in file", args: []string{"-config", badFile}, fails: true},
+        {name: "missing file", args: []string{"-config", filepath.Join(dir, "missing.conf")}, fails: true},
+    } {
+        t.Run(c.name, func(t *testing.T) {
+            flags := flag.NewFlagSet("server", flag.ContinueOnError)
+            flags.String("config", "", "")
+            retain := flags.Int("retain", 100, "")
+            authorRate := flags.Float64("author-rate", 5, "")
+            listen := &addresses{list: []string{":8080"}}
+            flags.Var(listen, "listen", "")
+            if err := flags.Parse(c.args); err != nil {
+                t.Fatal(err)
+            }
+            for name, value := range c.env {
+                t.Setenv(name, value)
+            }
+            err := configure(flags)
+            if c.fails {
+                if err == nil {
+                    t.Fatal("configured without an error")
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if *retain != c.retain || listen.String() != c.listen || *authorRate != c.author {
+                t.Errorf("got retain %d, listen %q and author-rate %v", *retain, listen, *authorRate)
+            }
+        })
+    }
+}
+
+func TestListenOnSeveralAddresses(t *testing.T) {
+    socket := filepath.Join(t.TempDir(), "chat.sock")
+    // A socket left behind by a server that crashed is taken over.
+    stale, err := net.Listen("unix", socket)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stale.(*net.UnixListener).SetUnlinkOnClose(false)
+    stale.Close()
+
+    server := grpc.NewServer()
+    chat.RegisterChatServer(server, newChatServer(eventbus.New(eventbus.Config{Queue: 10, Retain: 10})))
+    t.Cleanup(server.Stop)
+    var targets []string
+    for _, address := range []string{"127.0.0.1:0", "127.0.0.1:0", "unix:" + socket} {
+        lis, err := listenOn(address)
+        if err != nil {
+            t.Fatalf("%s: %v", address, err)
+        }
+        go server.Serve(lis)
+        if lis.Addr().Network() == "unix" {
+            targets = append(targets, "unix:"+lis.Addr().String())
+        } else {
+            targets = append(targets, lis.Addr().String())
+        }
+    }
+
+    // One running server answers on every address.
+    for _, target := range targets {
+        conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+        if err != nil {
+            t.Fatal(err)
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        _, err = chat.NewChatClient(conn).Access(ctx, &chat.AccessChange{Author: "Anders", Topic: "itu"})
+        cancel()
+        conn.Close()
+        if err != nil {
+            t.Errorf("%s: %v", target, err)
+        }
+    }
+
+    // The socket of a running server is not taken.
+    if lis, err := listenOn("unix:" + socket); err == nil {
+        lis.Close()
+        t.Fatal("listened on the socket of a running server")
+    }
+}
+
+func TestReceiveReplaysThenLive(t *testing.T) {
+    const logged, live = 50, 200
+    messages, err := msglog.Open(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer messages.Close()
+    bus := eventbus.New(eventbus.Config{Queue: *queue, Retain: 1, Log: eventLog{messages}})
+    s := newChatServer(bus)
+    conn, err := grpc.Dial(serve(t, s), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // The history is more than gRPC sends before the client reads, so the
+    // server is stuck streaming it until the test starts reading.
+    big := strings.Repeat("x", 8<<10)
+    for i := 1; i <= logged; i++ {
+        bus.Publish(eventbus.MessageEvent{Payload: eventbus.Chat{Text: big}, Topic: "itu", Author: "Anders"})
+    }
+    watch := make(eventbus.DataChannel)
+    bus.Subscribe("itu", watch, eventbus.Client{Author: "Test"})
+    stream, err := chat.NewChatClient(conn).Receive(ctx, &chat.Request{Author: "Emil", Topic: "itu", ReplayLast: logged})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Emil joining is #51, after which far more is published than the
+    // default queue holds.
+    if d := <-watch; d.Sequence != logged+1 {
+        t.Fatalf("got #%d", d.Sequence)
+    }
+    bus.Unsubscribe("itu", watch, eventbus.Client{Author: "Test"})
+    for range watch {
+    }
+    for i := 1; i <= live; i++ {
+        bus.Publish(eventbus.MessageEvent{Payload: eventbus.Chat{Text: fmt.Sprint(i)}, Topic: "itu", Author: "Anders"})
+    }
+
+    for want := uint64(1); want <= logged+1+live; want++ {
+        m, err := stream.Recv()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if m.Sequence != want {
+            t.Fatalf("got #%d instead of #%d", m.Sequence, want)
+        }
+    }
+}
+
+// The server takes in the clock a client sends, like any Lamport receive
+// event, so what it stamps comes after it.
+func TestClientClockIsMerged(t *testing.T) {
+    bus := eventbus.New(eventbus.Config{Queue: 10, Retain: 10})
+    s := newChatServer(bus)
+    conn, err := grpc.Dial(serve(t, s), grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    client := chat.NewChatClient(conn)
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    ch := make(eventbus.DataChannel)
+    bus.Subscribe("itu", ch, eventbus.Client{Author: "Emil"})
+    ack, err := client.Send(ctx, &chat.Message{Author: "Anders", Topic: "itu", Message: "hi", Lamport: 100})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ack.Lamport <= 100 {
+        t.Fatalf("message sent at 100 was stamped %d", ack.Lamport)
+    }
+    if d := <-ch; d.Lamport != int(ack.Lamport) {
+        t.Fatalf("message was stamped %d, but the ack says %d", d.Lamport, ack.Lamport)
+    }
+
+    // Subscribing takes in the clock of the subscriber too.
+    stream, err := client.Receive(ctx, &chat.Request{Author: "Sebastian", Topic: "itu", Lamport: 500})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if m, err := stream.Recv(); err != nil || m.Kind != chat.EventKind_JOIN || m.Lamport <= 500 {
+        t.Fatalf("got %v, %v", m, err)
+    }
+    // And a clock that is behind doesn't turn the server's back.
+    ack, err = client.Send(ctx, &chat.Message{Author: "Anders", Topic: "itu", Message: "hi", Lamport: 1})
+    if err != nil || ack.Lamport <= 500 {
+        t.Fatalf("got %v, %v", ack, err)
+    }
+}
@@ -0,0 +1,65 @@
+// Package vclock implements the vector clocks used by the EventBus in vector
+// mode. A clock has one entry per participant, keyed by author name.
+package vclock
+
+// VClock maps a participant to the number of events it has produced.
+type VClock map[string]uint64
+
+// Order is how two vector clocks relate to each other.
+type Order int
+
+const (
+	Equal Order = iota
+	Before
+	After
+	Concurrent
+)
+
+// Tick counts a new event by id.
+func (v VClock) Tick(id string) {
+	v[id]++
+}
+
+// Merge takes the entrywise maximum of v and other into v.
+func (v VClock) Merge(other VClock) {
+	for id, t := range other {
+		if t > v[id] {
+			v[id] = t
+		}
+	}
+}
+
+// Copy returns a clock that can be changed without affecting v.
+func (v VClock) Copy() VClock {
+	c := make(VClock, len(v))
+	for id, t := range v {
+		c[id] = t
+	}
+	return c
+}
+
+// Compare tells whether v happened before, after or concurrently with other.
+func (v VClock) Compare(other VClock) Order {
+	less, greater := false, false
+	for id, t := range v {
+		if t > other[id] {
+			greater = true
+		} else if t < other[id] {
+			less = true
+		}
+	}
+	for id, t := range other {
+		if _, found := v[id]; !found && t > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
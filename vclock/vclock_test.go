@@ -0,0 +1,75 @@
+package vclock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		v, w VClock
+		want Order
+	}{
+		{"both empty", VClock{}, VClock{}, Equal},
+		{"equal", VClock{"Anders": 2, "Emil": 1}, VClock{"Anders": 2, "Emil": 1}, Equal},
+		{"a zero entry is no entry", VClock{"Anders": 1, "Emil": 0}, VClock{"Anders": 1}, Equal},
+		{"before", VClock{"Anders": 1}, VClock{"Anders": 2}, Before},
+		{"before on another author", VClock{"Anders": 1}, VClock{"Anders": 1, "Emil": 1}, Before},
+		{"empty is before", VClock{}, VClock{"Emil": 1}, Before},
+		{"after", VClock{"Anders": 3, "Emil": 1}, VClock{"Anders": 2, "Emil": 1}, After},
+		{"after on another author", VClock{"Anders": 1, "Emil": 1}, VClock{"Anders": 1}, After},
+		{"concurrent", VClock{"Anders": 2, "Emil": 1}, VClock{"Anders": 1, "Emil": 2}, Concurrent},
+		{"concurrent on different authors", VClock{"Anders": 1}, VClock{"Emil": 1}, Concurrent},
+	} {
+		if got := c.v.Compare(c.w); got != c.want {
+			t.Errorf("%s: %v compared to %v is %v, want %v", c.name, c.v, c.w, got, c.want)
+		}
+		// The other way around the order is reversed.
+		want := map[Order]Order{Equal: Equal, Before: After, After: Before, Concurrent: Concurrent}[c.want]
+		if got := c.w.Compare(c.v); got != want {
+			t.Errorf("%s: %v compared to %v is %v, want %v", c.name, c.w, c.v, got, want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		v, w VClock
+		want VClock
+	}{
+		{"equal", VClock{"Anders": 2}, VClock{"Anders": 2}, VClock{"Anders": 2}},
+		{"before", VClock{"Anders": 1}, VClock{"Anders": 2, "Emil": 1}, VClock{"Anders": 2, "Emil": 1}},
+		{"after", VClock{"Anders": 3, "Emil": 1}, VClock{"Anders": 2}, VClock{"Anders": 3, "Emil": 1}},
+		{"concurrent", VClock{"Anders": 2, "Emil": 1}, VClock{"Anders": 1, "Emil": 2, "Sebastian": 1}, VClock{"Anders": 2, "Emil": 2, "Sebastian": 1}},
+		{"empty", VClock{}, VClock{"Emil": 1}, VClock{"Emil": 1}},
+	} {
+		w := c.w.Copy()
+		v := c.v.Copy()
+		v.Merge(w)
+		if !reflect.DeepEqual(v, c.want) {
+			t.Errorf("%s: %v merged with %v is %v, want %v", c.name, c.v, c.w, v, c.want)
+		}
+		if !reflect.DeepEqual(w, c.w) {
+			t.Errorf("%s: merging changed the other clock to %v", c.name, w)
+		}
+		// The merged clock happened after or at both clocks.
+		if o := v.Compare(c.v); o != After && o != Equal {
+			t.Errorf("%s: merged clock is %v the first", c.name, o)
+		}
+		if o := v.Compare(c.w); o != After && o != Equal {
+			t.Errorf("%s: merged clock is %v the second", c.name, o)
+		}
+	}
+}
+
+func TestTickAndCopy(t *testing.T) {
+	v := VClock{}
+	v.Tick("Anders")
+	c := v.Copy()
+	v.Tick("Anders")
+	if c["Anders"] != 1 || v["Anders"] != 2 || c.Compare(v) != Before {
+		t.Errorf("got copy %v of %v", c, v)
+	}
+}